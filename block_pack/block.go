@@ -3,7 +3,6 @@ package block_pack
 import (
 	"encoding/json"
 	"strconv"
-	"strings"
 
 	"github.com/modulrcloud/modulr-anchors-core/cryptography"
 	"github.com/modulrcloud/modulr-anchors-core/globals"
@@ -19,9 +18,11 @@ type Block struct {
 	Index     int              `json:"index"`
 	PrevHash  string           `json:"prevHash"`
 	Sig       string           `json:"sig"`
+	Version   int              `json:"version,omitempty"`
 }
 
-func NewBlock(extraData ExtraDataToBlock, epochFullID string, metadata *structures.GenerationThreadMetadataHandler) *Block {
+func NewBlock(extraData ExtraDataToBlock, epochHandler *structures.EpochDataHandler, metadata *structures.GenerationThreadMetadataHandler) *Block {
+	epochFullID := epochHandler.Hash + "#" + strconv.Itoa(epochHandler.Id)
 	return &Block{
 		Creator:   globals.CONFIGURATION.PublicKey,
 		Time:      utils.GetUTCTimestampInMilliSeconds(),
@@ -30,6 +31,7 @@ func NewBlock(extraData ExtraDataToBlock, epochFullID string, metadata *structur
 		Index:     metadata.NextIndex,
 		PrevHash:  metadata.PrevHash,
 		Sig:       "",
+		Version:   epochHandler.SigningPayloadVersion,
 	}
 }
 
@@ -41,7 +43,8 @@ func (block *Block) GetHash() string {
 		panic("GetHash: failed to marshal extraData: " + err.Error())
 	}
 
-	dataToHash := strings.Join([]string{
+	dataToHash := utils.GetBlockHashPayload(
+		block.Version,
 		block.Creator,
 		strconv.FormatInt(block.Time, 10),
 		globals.GENESIS.NetworkId,
@@ -49,7 +52,7 @@ func (block *Block) GetHash() string {
 		string(jsonedExtraData),
 		strconv.Itoa(block.Index),
 		block.PrevHash,
-	}, ":")
+	)
 
 	return utils.Blake3(dataToHash)
 }
@@ -65,3 +68,95 @@ func (block *Block) VerifySignature() bool {
 	return cryptography.VerifySignature(block.GetHash(), block.Creator, block.Sig)
 
 }
+
+// VerifyExtraData checks leader finalization proofs, membership requests, governance proofs, evidences and transactions carried in block.
+// All of them should be signed properly and target the block epoch (ALFPs - the block epoch or any earlier one with known handler).
+// Caller should hold APPROVEMENT_THREAD_METADATA lock
+func (block *Block) VerifyExtraData(epochHandler *structures.EpochDataHandler) bool {
+
+	for idx := range block.ExtraData.AggregatedLeaderFinalizationProofs {
+
+		proof := &block.ExtraData.AggregatedLeaderFinalizationProofs[idx]
+
+		// ALFPs of epochs after the block epoch can't be verified by voters which are still in the block epoch.
+		// Handlers of older epochs are stored on rotation and never pruned, so every voter checks them the same way
+		// regardless of its supported window. ALFP which can't be verified rejects the block
+
+		if proof.EpochIndex > epochHandler.Id {
+			return false
+		}
+
+		proofEpochHandler := utils.GetEpochHandlerByIDUnlocked(proof.EpochIndex)
+
+		if proofEpochHandler == nil {
+			proofEpochHandler, _ = utils.LoadEpochHandler(proof.EpochIndex)
+		}
+
+		if proofEpochHandler == nil || utils.VerifyAggregatedLeaderFinalizationProof(proof, proofEpochHandler) != nil {
+			return false
+		}
+
+	}
+
+	for idx := range block.ExtraData.AnchorMembershipRequests {
+
+		request := &block.ExtraData.AnchorMembershipRequests[idx]
+
+		if utils.VerifyAnchorMembershipRequest(request, epochHandler) != nil {
+			return false
+		}
+
+	}
+
+	for idx := range block.ExtraData.AggregatedNetworkParametersProofs {
+
+		if utils.VerifyAggregatedNetworkParametersProof(&block.ExtraData.AggregatedNetworkParametersProofs[idx], epochHandler) != nil {
+			return false
+		}
+
+	}
+
+	for idx := range block.ExtraData.BlockEquivocationEvidences {
+
+		if VerifyBlockEquivocationEvidence(&block.ExtraData.BlockEquivocationEvidences[idx], epochHandler) != nil {
+			return false
+		}
+
+	}
+
+	for idx := range block.ExtraData.DoubleVoteEvidences {
+
+		if utils.VerifyDoubleVoteEvidence(&block.ExtraData.DoubleVoteEvidences[idx], epochHandler) != nil {
+			return false
+		}
+
+	}
+
+	// Transactions are carried only by blocks of their creator, so nonces of anchor are finalized by one chain of blocks
+	// and block of another creator can't be invalidated by them. Nonces should strictly grow inside the block
+
+	lastNonces := make(map[string]uint64)
+
+	for idx := range block.ExtraData.Transactions {
+
+		tx := &block.ExtraData.Transactions[idx]
+
+		if tx.Creator != block.Creator {
+			return false
+		}
+
+		if lastNonce, ok := lastNonces[tx.Creator]; ok && tx.Nonce <= lastNonce {
+			return false
+		}
+
+		if utils.VerifyAnchorTransaction(tx, epochHandler) != nil {
+			return false
+		}
+
+		lastNonces[tx.Creator] = tx.Nonce
+
+	}
+
+	return true
+
+}
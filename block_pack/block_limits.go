@@ -0,0 +1,100 @@
+package block_pack
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/modulrcloud/modulr-anchors-core/structures"
+)
+
+// Reserved bytes for the key of each extra data list in serialized block
+const extraDataFieldOverhead = 64
+
+// ItemsCount returns the number of proofs, requests, evidences and transactions in extra data. Each of them counts against TxLimitPerBlock
+func (extra *ExtraDataToBlock) ItemsCount() int {
+
+	return len(extra.AggregatedAnchorRotationProofs) + len(extra.AggregatedLeaderFinalizationProofs) + len(extra.AnchorMembershipRequests) +
+		len(extra.AggregatedNetworkParametersProofs) + len(extra.BlockEquivocationEvidences) + len(extra.DoubleVoteEvidences) + len(extra.Transactions)
+
+}
+
+func (block *Block) SizeInBytes() int {
+
+	blockBytes, err := json.Marshal(block)
+
+	if err != nil {
+		return 0
+	}
+
+	return len(blockBytes)
+
+}
+
+// VerifyLimits checks block against MaxBlockSizeInBytes and TxLimitPerBlock network parameters
+func (block *Block) VerifyLimits(networkParams *structures.NetworkParameters) error {
+
+	if itemsCount := block.ExtraData.ItemsCount(); itemsCount > networkParams.TxLimitPerBlock {
+		return fmt.Errorf("block contains %d items, limit is %d", itemsCount, networkParams.TxLimitPerBlock)
+	}
+
+	if size := block.SizeInBytes(); int64(size) > networkParams.MaxBlockSizeInBytes {
+		return fmt.Errorf("block size is %d bytes, limit is %d", size, networkParams.MaxBlockSizeInBytes)
+	}
+
+	return nil
+
+}
+
+// PackExtraData moves items from candidates to the block extra data while it stays within limits.
+// The items which don't fit are returned as leftovers to be included to the next blocks.
+// The items which can't fit even to the empty block are returned as dropped - they would stay in mempool forever
+func PackExtraData(candidates ExtraDataToBlock, maxItems int, bytesBudget int) (ExtraDataToBlock, ExtraDataToBlock, ExtraDataToBlock) {
+
+	packed := ExtraDataToBlock{Rest: candidates.Rest}
+
+	leftovers, dropped := ExtraDataToBlock{}, ExtraDataToBlock{}
+
+	bytesBudget -= 7 * extraDataFieldOverhead
+
+	maxItemSize := bytesBudget
+
+	packItems(candidates.AggregatedAnchorRotationProofs, &packed.AggregatedAnchorRotationProofs, &leftovers.AggregatedAnchorRotationProofs, &dropped.AggregatedAnchorRotationProofs, &maxItems, &bytesBudget, maxItemSize)
+	packItems(candidates.AggregatedLeaderFinalizationProofs, &packed.AggregatedLeaderFinalizationProofs, &leftovers.AggregatedLeaderFinalizationProofs, &dropped.AggregatedLeaderFinalizationProofs, &maxItems, &bytesBudget, maxItemSize)
+	packItems(candidates.AnchorMembershipRequests, &packed.AnchorMembershipRequests, &leftovers.AnchorMembershipRequests, &dropped.AnchorMembershipRequests, &maxItems, &bytesBudget, maxItemSize)
+	packItems(candidates.AggregatedNetworkParametersProofs, &packed.AggregatedNetworkParametersProofs, &leftovers.AggregatedNetworkParametersProofs, &dropped.AggregatedNetworkParametersProofs, &maxItems, &bytesBudget, maxItemSize)
+	packItems(candidates.BlockEquivocationEvidences, &packed.BlockEquivocationEvidences, &leftovers.BlockEquivocationEvidences, &dropped.BlockEquivocationEvidences, &maxItems, &bytesBudget, maxItemSize)
+	packItems(candidates.DoubleVoteEvidences, &packed.DoubleVoteEvidences, &leftovers.DoubleVoteEvidences, &dropped.DoubleVoteEvidences, &maxItems, &bytesBudget, maxItemSize)
+	packItems(candidates.Transactions, &packed.Transactions, &leftovers.Transactions, &dropped.Transactions, &maxItems, &bytesBudget, maxItemSize)
+
+	return packed, leftovers, dropped
+
+}
+
+func packItems[T any](items []T, packed, leftovers, dropped *[]T, itemsLeft, bytesLeft *int, maxItemSize int) {
+
+	for _, item := range items {
+
+		itemBytes, err := json.Marshal(item)
+
+		// +1 for the separator in JSON array
+		itemSize := len(itemBytes) + 1
+
+		if err != nil || itemSize > maxItemSize {
+			*dropped = append(*dropped, item)
+			continue
+		}
+
+		if *itemsLeft <= 0 || itemSize > *bytesLeft {
+			*leftovers = append(*leftovers, item)
+			continue
+		}
+
+		*packed = append(*packed, item)
+
+		*itemsLeft--
+
+		*bytesLeft -= itemSize
+
+	}
+
+}
@@ -0,0 +1,368 @@
+package block_pack
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/modulrcloud/modulr-anchors-core/databases"
+	"github.com/modulrcloud/modulr-anchors-core/globals"
+	"github.com/modulrcloud/modulr-anchors-core/structures"
+	"github.com/modulrcloud/modulr-anchors-core/utils"
+)
+
+// Membership requests and network parameters proofs of epoch are applied when the next epoch is created. Once the time of epoch
+// is over, the quorum agrees on the last finalized block of each creator (aggregated epoch finish proof), so every node takes
+// the changes from the same blocks - finalized ones up to the heights of the proof. Changes are collected in background
+// (proof and blocks may be fetched from other anchors) and stored, rotation only reads them
+
+// EpochChanges are changes of epoch to apply to the registry and parameters of the next epoch
+type EpochChanges struct {
+	MembershipRequests []structures.AnchorMembershipRequest  `json:"membershipRequests"` // one request per anchor, ordered by pubkey
+	NetworkParameters  *structures.NetworkParametersProposal `json:"networkParameters,omitempty"`
+}
+
+// Epochs for which background collection of changes was tried at least once since start
+var epochChangesAttempts sync.Map
+
+// CollectEpochChanges reads finalized blocks of epoch up to the heights of its epoch finish proof. The proof and blocks
+// which node doesn't have are fetched from anchors of epoch. Error means that they are not available yet
+func CollectEpochChanges(epochHandler *structures.EpochDataHandler) (*EpochChanges, error) {
+
+	defer epochChangesAttempts.Store(epochHandler.Id, true)
+
+	proof, err := loadOrFetchEpochFinishProof(epochHandler)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return collectEpochChangesFromBlocks(epochHandler, proof, getFinalizedBlock)
+
+}
+
+func epochChangesKey(epochIndex int) []byte {
+	return []byte("EPOCH_CHANGES:" + strconv.Itoa(epochIndex))
+}
+
+func StoreEpochChanges(epochIndex int, changes *EpochChanges) error {
+
+	payload, err := json.Marshal(changes)
+
+	if err != nil {
+		return err
+	}
+
+	return databases.EPOCH_DATA.Put(epochChangesKey(epochIndex), payload)
+
+}
+
+func LoadEpochChanges(epochIndex int) (*EpochChanges, error) {
+
+	raw, err := databases.EPOCH_DATA.Get(epochChangesKey(epochIndex))
+
+	if err != nil {
+		return nil, err
+	}
+
+	var changes EpochChanges
+
+	if err := json.Unmarshal(raw, &changes); err != nil {
+		return nil, err
+	}
+
+	return &changes, nil
+
+}
+
+// EpochChangesForRotation returns changes of epoch to build the next one. Until the deadline (the end of epoch plus a quarter
+// of its duration - the same on every node) rotation waits for the changes. After it, and after at least one attempt
+// to collect them since start, the next epoch is built without changes. The second return value is false while rotation should wait
+func EpochChangesForRotation(epochHandler *structures.EpochDataHandler, params *structures.NetworkParameters) (*EpochChanges, bool) {
+
+	if changes, err := LoadEpochChanges(epochHandler.Id); err == nil {
+		return changes, true
+	}
+
+	deadline := epochHandler.StartTimestamp + uint64(params.EpochDuration) + uint64(params.EpochDuration)/4
+
+	if _, attempted := epochChangesAttempts.Load(epochHandler.Id); !attempted || uint64(utils.GetUTCTimestampInMilliSeconds()) < deadline {
+		return nil, false
+	}
+
+	return &EpochChanges{}, true
+
+}
+
+// finalizedBlockGetter returns block of creator with the expected hash
+type finalizedBlockGetter func(epochHandler *structures.EpochDataHandler, creator string, index int, expectedHash string) (*Block, error)
+
+func collectEpochChangesFromBlocks(epochHandler *structures.EpochDataHandler, proof *structures.AggregatedEpochFinishProof, getBlock finalizedBlockGetter) (*EpochChanges, error) {
+
+	creators := make([]string, 0, len(proof.Summary.VotingStats))
+
+	for creator := range proof.Summary.VotingStats {
+		creators = append(creators, creator)
+	}
+
+	sort.Strings(creators)
+
+	membershipRequests := make(map[string]structures.AnchorMembershipRequest)
+
+	proposals := []structures.NetworkParametersProposal{}
+
+	for _, creator := range creators {
+
+		stat := proof.Summary.VotingStats[creator]
+
+		expectedHash := stat.Hash
+
+		// Blocks are linked by PrevHash, so the whole finalized segment is restored from the hash of the last block
+
+		for index := stat.Index; index >= 0; index-- {
+
+			block, err := getBlock(epochHandler, creator, index, expectedHash)
+
+			if err != nil {
+				return nil, err
+			}
+
+			for _, request := range block.ExtraData.AnchorMembershipRequests {
+
+				if utils.VerifyAnchorMembershipRequest(&request, epochHandler) != nil {
+					continue
+				}
+
+				// Several requests of the same anchor - the one with the smallest signing data hash wins
+
+				if existing, ok := membershipRequests[request.Anchor.Pubkey]; ok && utils.Blake3(utils.GetAnchorMembershipSigningData(&existing)) <= utils.Blake3(utils.GetAnchorMembershipSigningData(&request)) {
+					continue
+				}
+
+				membershipRequests[request.Anchor.Pubkey] = request
+
+			}
+
+			for idx := range block.ExtraData.AggregatedNetworkParametersProofs {
+
+				networkParametersProof := &block.ExtraData.AggregatedNetworkParametersProofs[idx]
+
+				if utils.VerifyAggregatedNetworkParametersProof(networkParametersProof, epochHandler) == nil {
+					proposals = append(proposals, networkParametersProof.Proposal)
+				}
+
+			}
+
+			expectedHash = block.PrevHash
+
+		}
+
+	}
+
+	changes := &EpochChanges{MembershipRequests: make([]structures.AnchorMembershipRequest, 0, len(membershipRequests))}
+
+	for _, request := range membershipRequests {
+		changes.MembershipRequests = append(changes.MembershipRequests, request)
+	}
+
+	sort.Slice(changes.MembershipRequests, func(i, j int) bool {
+		return changes.MembershipRequests[i].Anchor.Pubkey < changes.MembershipRequests[j].Anchor.Pubkey
+	})
+
+	// If several proposals were finalized during the epoch - the one with the smallest signing data hash wins
+
+	sort.Slice(proposals, func(i, j int) bool {
+		return utils.GetNetworkParametersProposalHash(&proposals[i]) < utils.GetNetworkParametersProposalHash(&proposals[j])
+	})
+
+	if len(proposals) > 0 {
+		changes.NetworkParameters = &proposals[0]
+	}
+
+	return changes, nil
+
+}
+
+// BuildNextEpochHandler derives the handler of the epoch after epochHandler. params are network parameters of epochHandler,
+// changes are changes of epochHandler. Storages in knownAnchors are used for weights instead of the stored ones.
+// Returns the handler, network parameters of the next epoch and storages of joined anchors
+func BuildNextEpochHandler(epochHandler *structures.EpochDataHandler, params structures.NetworkParameters, changes *EpochChanges, knownAnchors map[string]structures.AnchorStorage) (structures.EpochDataHandler, structures.NetworkParameters, map[string]structures.AnchorStorage) {
+
+	nextEpochHash := utils.Blake3(epochHandler.Hash)
+
+	nextEpochRegistry, joinedAnchors := getNextEpochAnchorsRegistry(epochHandler.AnchorsRegistry, changes.MembershipRequests)
+
+	nextEpochHandler := structures.EpochDataHandler{
+		Id:              epochHandler.Id + 1,
+		Hash:            nextEpochHash,
+		AnchorsRegistry: nextEpochRegistry,
+		Quorum:          []string{}, // will be assigned
+		StartTimestamp:  epochHandler.StartTimestamp + uint64(params.EpochDuration),
+	}
+
+	// Network parameters approved by quorum are applied from the next epoch
+
+	nextEpochParams := params.CopyNetworkParameters()
+
+	if changes.NetworkParameters != nil {
+		nextEpochParams = changes.NetworkParameters.Parameters.CopyNetworkParameters()
+	}
+
+	nextEpochHandler.SigningPayloadVersion = nextEpochParams.SigningPayloadVersion
+
+	// Assign quorum - pseudorandomly and in deterministic way
+
+	nextEpochHandler.Quorum = utils.GetCurrentEpochQuorum(&nextEpochHandler, nextEpochParams.QuorumSize, nextEpochHash)
+
+	weightSources := maps.Clone(knownAnchors)
+
+	if weightSources == nil {
+		weightSources = make(map[string]structures.AnchorStorage, len(joinedAnchors))
+	}
+
+	maps.Copy(weightSources, joinedAnchors)
+
+	nextEpochHandler.QuorumWeights = utils.SnapshotQuorumWeights(nextEpochHandler.Quorum, weightSources, &nextEpochParams)
+
+	return nextEpochHandler, nextEpochParams, joinedAnchors
+
+}
+
+// getNextEpochAnchorsRegistry applies join/leave requests to the anchors registry. Storages of left anchors are kept for older epochs
+func getNextEpochAnchorsRegistry(registry []string, requests []structures.AnchorMembershipRequest) ([]string, map[string]structures.AnchorStorage) {
+
+	nextRegistry := make([]string, len(registry))
+
+	copy(nextRegistry, registry)
+
+	joinedAnchors := make(map[string]structures.AnchorStorage)
+
+	for _, request := range requests {
+
+		position := slices.Index(nextRegistry, request.Anchor.Pubkey)
+
+		switch request.Type {
+
+		case structures.ANCHOR_MEMBERSHIP_JOIN:
+
+			// Members can't rewrite their storages (urls, keys) by joining again
+
+			if position >= 0 {
+				continue
+			}
+
+			joinedAnchors[request.Anchor.Pubkey] = request.Anchor
+
+			nextRegistry = append(nextRegistry, request.Anchor.Pubkey)
+
+		case structures.ANCHOR_MEMBERSHIP_LEAVE:
+
+			// Registry can't become empty
+
+			if position < 0 || len(nextRegistry) == 1 {
+				continue
+			}
+
+			nextRegistry = slices.Delete(nextRegistry, position, position+1)
+
+		}
+
+	}
+
+	return nextRegistry, joinedAnchors
+
+}
+
+func loadOrFetchEpochFinishProof(epochHandler *structures.EpochDataHandler) (*structures.AggregatedEpochFinishProof, error) {
+
+	if proof, err := utils.LoadAggregatedEpochFinishProof(epochHandler.Id); err == nil {
+		return &proof, nil
+	}
+
+	for _, url := range getEpochAnchorsUrls(epochHandler) {
+
+		body, status, err := utils.GetJSON(url + "/aggregated_epoch_finish_proof/" + strconv.Itoa(epochHandler.Id))
+
+		if err != nil || status != http.StatusOK {
+			continue
+		}
+
+		var proof structures.AggregatedEpochFinishProof
+
+		if json.Unmarshal(body, &proof) != nil || utils.VerifyAggregatedEpochFinishProof(&proof, epochHandler) != nil {
+			continue
+		}
+
+		if err := utils.StoreAggregatedEpochFinishProof(proof); err != nil {
+			return nil, err
+		}
+
+		return &proof, nil
+
+	}
+
+	return nil, fmt.Errorf("epoch finish proof of epoch %d is not available yet", epochHandler.Id)
+
+}
+
+// getFinalizedBlock returns block of creator with the expected hash. Blocks fetched from other anchors are stored locally
+func getFinalizedBlock(epochHandler *structures.EpochDataHandler, creator string, index int, expectedHash string) (*Block, error) {
+
+	blockId := strconv.Itoa(epochHandler.Id) + ":" + creator + ":" + strconv.Itoa(index)
+
+	if block, err := LoadBlock(blockId); err == nil && strings.EqualFold(block.GetHash(), expectedHash) {
+		return block, nil
+	}
+
+	for _, url := range getEpochAnchorsUrls(epochHandler) {
+
+		body, status, err := utils.GetJSON(url + "/block/" + blockId)
+
+		if err != nil || status != http.StatusOK {
+			continue
+		}
+
+		var block Block
+
+		if json.Unmarshal(body, &block) != nil || !strings.EqualFold(block.GetHash(), expectedHash) {
+			continue
+		}
+
+		if err := databases.BLOCKS.Put([]byte(blockId), body); err != nil {
+			return nil, err
+		}
+
+		return &block, nil
+
+	}
+
+	return nil, errors.New("finalized block " + blockId + " is not available yet")
+
+}
+
+func getEpochAnchorsUrls(epochHandler *structures.EpochDataHandler) []string {
+
+	urls := []string{}
+
+	for _, pubkey := range epochHandler.AnchorsRegistry {
+
+		if pubkey == globals.CONFIGURATION.PublicKey {
+			continue
+		}
+
+		if anchorStorage := utils.GetAnchorFromApprovementThreadState(pubkey); anchorStorage != nil && anchorStorage.AnchorUrl != "" {
+			urls = append(urls, strings.TrimRight(anchorStorage.AnchorUrl, "/"))
+		}
+
+	}
+
+	return urls
+
+}
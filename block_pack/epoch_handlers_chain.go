@@ -0,0 +1,308 @@
+package block_pack
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/modulrcloud/modulr-anchors-core/databases"
+	"github.com/modulrcloud/modulr-anchors-core/globals"
+	"github.com/modulrcloud/modulr-anchors-core/structures"
+	"github.com/modulrcloud/modulr-anchors-core/utils"
+)
+
+// Data of epoch (epoch finish proof or finalized blocks) isn't in snapshot - epoch was pruned by the exporter
+var errEpochDataMissing = errors.New("snapshot has no data of epoch")
+
+// VerifyEpochHandlersChain checks epoch handlers of restored snapshot starting from the local genesis. Handler of epoch 0 is
+// derived from genesis, every next one is derived from the previous handler and its changes - the same way as on rotation,
+// with the epoch finish proof verified by the quorum of that epoch and the blocks of snapshot (or without changes, if rotation
+// didn't get them in time). Pruning marker of the archive is never used: if changes of some epoch can't be replayed because
+// its data is missing, import fails unless trustPruned is set. Then this and later handlers are checked by structure only.
+// Stored handlers, AT and anchors storages should match the verified chain. Returns the last pruned epoch of snapshot or -1
+func VerifyEpochHandlersChain(epochHandlers map[int]structures.EpochDataHandler, trustPruned bool) (int, error) {
+
+	lastEpochId := -1
+
+	for id := range epochHandlers {
+		lastEpochId = max(lastEpochId, id)
+	}
+
+	if len(epochHandlers) == 0 || len(epochHandlers) != lastEpochId+1 {
+		return -1, errors.New("snapshot should contain handlers of all epochs from genesis")
+	}
+
+	params := globals.GENESIS.NetworkParameters.CopyNetworkParameters()
+
+	if !sameEpochHandler(utils.BuildGenesisEpochHandler(&params), epochHandlers[0]) {
+		return -1, errors.New("handler of epoch 0 doesn't match local genesis")
+	}
+
+	anchors := make(map[string]structures.AnchorStorage, len(globals.GENESIS.Anchors))
+
+	for _, anchorStorage := range globals.GENESIS.Anchors {
+		anchors[anchorStorage.Pubkey] = anchorStorage
+	}
+
+	prunedEpoch, replayed := -1, true
+
+	for id := 1; id <= lastEpochId; id++ {
+
+		previous, epochHandler := epochHandlers[id-1], epochHandlers[id]
+
+		// Handler of epoch id is created with changes of epoch id-1
+
+		changes, err := collectLocalEpochChanges(previous)
+
+		// Exporter prunes epochs from genesis, so only the prefix of epochs without data is pruned
+
+		if errors.Is(err, errEpochDataMissing) && prunedEpoch == id-2 {
+			prunedEpoch = id - 1
+		}
+
+		if !replayed {
+
+			if err := verifyEpochHandlerStructure(&previous, &epochHandler); err != nil {
+				return -1, fmt.Errorf("epoch %d: %w", id, err)
+			}
+
+			continue
+
+		}
+
+		// Rotation builds the next epoch without changes if they were not collected in time
+
+		candidates := []*EpochChanges{{}}
+
+		if err == nil {
+			candidates = []*EpochChanges{changes, {}}
+		}
+
+		matched := false
+
+		var nextParams structures.NetworkParameters
+
+		var joinedAnchors map[string]structures.AnchorStorage
+
+		for _, candidate := range candidates {
+
+			var expected structures.EpochDataHandler
+
+			expected, nextParams, joinedAnchors = BuildNextEpochHandler(&previous, params, candidate, anchors)
+
+			if matched = sameEpochHandler(expected, epochHandler); matched {
+				break
+			}
+
+		}
+
+		if !matched {
+
+			if !errors.Is(err, errEpochDataMissing) {
+				return -1, fmt.Errorf("handler of epoch %d doesn't match the one derived from epoch %d", id, id-1)
+			}
+
+			if !trustPruned {
+				return -1, fmt.Errorf("changes of epoch %d can't be replayed: %w. Snapshot of pruned node is imported only with --trust-pruned", id-1, err)
+			}
+
+			if err := verifyEpochHandlerStructure(&previous, &epochHandler); err != nil {
+				return -1, fmt.Errorf("epoch %d: %w", id, err)
+			}
+
+			replayed = false
+
+			continue
+
+		}
+
+		params = nextParams
+
+		maps.Copy(anchors, joinedAnchors)
+
+	}
+
+	if err := verifyStoredEpochHandlers(epochHandlers); err != nil {
+		return -1, err
+	}
+
+	if !replayed {
+		return prunedEpoch, nil
+	}
+
+	// Network parameters and anchors storages are known only when the whole chain was replayed
+
+	if err := verifyStoredNetworkParameters(&params); err != nil {
+		return -1, err
+	}
+
+	return prunedEpoch, verifyStoredAnchors(anchors)
+
+}
+
+// verifyEpochHandlerStructure checks what can be checked without the changes of epoch: link with the previous handler,
+// quorum selected from the registry by the epoch hash and weights of quorum members
+func verifyEpochHandlerStructure(previous, epochHandler *structures.EpochDataHandler) error {
+
+	if epochHandler.Id != previous.Id+1 || epochHandler.Hash != utils.Blake3(previous.Hash) {
+		return errors.New("handler isn't linked with the previous one")
+	}
+
+	if epochHandler.StartTimestamp <= previous.StartTimestamp {
+		return errors.New("epoch starts before the previous one")
+	}
+
+	if len(epochHandler.Quorum) == 0 || !slices.Equal(epochHandler.Quorum, utils.GetCurrentEpochQuorum(epochHandler, len(epochHandler.Quorum), epochHandler.Hash)) {
+		return errors.New("quorum isn't selected from the registry by epoch hash")
+	}
+
+	if len(epochHandler.QuorumWeights) != len(epochHandler.Quorum) {
+		return errors.New("weights should be set for each quorum member")
+	}
+
+	for _, pubkey := range epochHandler.Quorum {
+		if epochHandler.QuorumWeights[pubkey] == 0 {
+			return errors.New("weights should be set for each quorum member")
+		}
+	}
+
+	return nil
+
+}
+
+// collectLocalEpochChanges reads changes of epoch from the restored chaindata only
+func collectLocalEpochChanges(epochHandler structures.EpochDataHandler) (*EpochChanges, error) {
+
+	proof, err := utils.LoadAggregatedEpochFinishProof(epochHandler.Id)
+
+	if errors.Is(err, databases.ErrNotFound) {
+		return nil, fmt.Errorf("%w %d: no epoch finish proof", errEpochDataMissing, epochHandler.Id)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := utils.VerifyAggregatedEpochFinishProof(&proof, &epochHandler); err != nil {
+		return nil, err
+	}
+
+	return collectEpochChangesFromBlocks(&epochHandler, &proof, loadLocalFinalizedBlock)
+
+}
+
+func loadLocalFinalizedBlock(epochHandler *structures.EpochDataHandler, creator string, index int, expectedHash string) (*Block, error) {
+
+	blockId := strconv.Itoa(epochHandler.Id) + ":" + creator + ":" + strconv.Itoa(index)
+
+	block, err := LoadBlock(blockId)
+
+	if errors.Is(err, databases.ErrNotFound) {
+		return nil, fmt.Errorf("%w %d: no finalized block %s", errEpochDataMissing, epochHandler.Id, blockId)
+	}
+
+	if err != nil || block.GetHash() != expectedHash {
+		return nil, errors.New("snapshot has another block " + blockId)
+	}
+
+	return block, nil
+
+}
+
+func verifyStoredEpochHandlers(epochHandlers map[int]structures.EpochDataHandler) error {
+
+	for id, epochHandler := range epochHandlers {
+
+		raw, err := databases.EPOCH_DATA.Get([]byte("EPOCH_HANDLER:" + strconv.Itoa(id)))
+
+		if err != nil {
+			continue // supported epoch which is not rotated yet
+		}
+
+		var stored structures.EpochDataHandler
+
+		if json.Unmarshal(raw, &stored) != nil || !sameEpochHandler(stored, epochHandler) {
+			return fmt.Errorf("stored handler of epoch %d differs from the snapshot header", id)
+		}
+
+	}
+
+	raw, err := databases.APPROVEMENT_THREAD_METADATA.Get([]byte("AT"))
+
+	if err != nil {
+		return err
+	}
+
+	var atHandler structures.ApprovementThreadMetadataHandler
+
+	if err := json.Unmarshal(raw, &atHandler); err != nil {
+		return err
+	}
+
+	for _, epochHandler := range append([]structures.EpochDataHandler{atHandler.EpochDataHandler}, atHandler.SupportedEpochs...) {
+
+		if !sameEpochHandler(epochHandler, epochHandlers[epochHandler.Id]) {
+			return fmt.Errorf("handler of epoch %d in AT differs from the snapshot header", epochHandler.Id)
+		}
+
+	}
+
+	return nil
+
+}
+
+func verifyStoredNetworkParameters(params *structures.NetworkParameters) error {
+
+	raw, err := databases.APPROVEMENT_THREAD_METADATA.Get([]byte("AT"))
+
+	if err != nil {
+		return err
+	}
+
+	var atHandler structures.ApprovementThreadMetadataHandler
+
+	if err := json.Unmarshal(raw, &atHandler); err != nil {
+		return err
+	}
+
+	if !sameJSON(&atHandler.NetworkParameters, params) {
+		return errors.New("network parameters in AT differ from the ones derived from genesis and approved proposals")
+	}
+
+	return nil
+
+}
+
+func verifyStoredAnchors(anchors map[string]structures.AnchorStorage) error {
+
+	for pubkey, anchorStorage := range anchors {
+
+		storedStorage := utils.GetAnchorFromApprovementThreadState(pubkey)
+
+		if storedStorage == nil || !sameJSON(storedStorage, &anchorStorage) {
+			return errors.New("storage of anchor " + pubkey + " differs from genesis and membership requests")
+		}
+
+	}
+
+	return nil
+
+}
+
+func sameEpochHandler(first, second structures.EpochDataHandler) bool {
+	return sameJSON(&first, &second)
+}
+
+func sameJSON(first, second any) bool {
+
+	firstJSON, firstErr := json.Marshal(first)
+
+	secondJSON, secondErr := json.Marshal(second)
+
+	return firstErr == nil && secondErr == nil && bytes.Equal(firstJSON, secondJSON)
+
+}
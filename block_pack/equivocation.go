@@ -0,0 +1,76 @@
+package block_pack
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+
+	"github.com/modulrcloud/modulr-anchors-core/structures"
+)
+
+func NewBlockEquivocationEvidence(firstBlock, secondBlock *Block, epochIndex int) (structures.BlockEquivocationEvidence, error) {
+
+	firstBlockJson, err := json.Marshal(firstBlock)
+
+	if err != nil {
+		return structures.BlockEquivocationEvidence{}, err
+	}
+
+	secondBlockJson, err := json.Marshal(secondBlock)
+
+	if err != nil {
+		return structures.BlockEquivocationEvidence{}, err
+	}
+
+	return structures.BlockEquivocationEvidence{
+		EpochIndex:  epochIndex,
+		BlockId:     strconv.Itoa(epochIndex) + ":" + firstBlock.Creator + ":" + strconv.Itoa(firstBlock.Index),
+		FirstBlock:  firstBlockJson,
+		SecondBlock: secondBlockJson,
+	}, nil
+
+}
+
+// VerifyBlockEquivocationEvidence checks that both blocks are signed by the same creator of epoch, have the same id and different hashes
+func VerifyBlockEquivocationEvidence(evidence *structures.BlockEquivocationEvidence, epochHandler *structures.EpochDataHandler) error {
+
+	var firstBlock, secondBlock Block
+
+	if json.Unmarshal(evidence.FirstBlock, &firstBlock) != nil || json.Unmarshal(evidence.SecondBlock, &secondBlock) != nil {
+		return errors.New("invalid blocks in evidence")
+	}
+
+	if evidence.EpochIndex != epochHandler.Id {
+		return errors.New("evidence epoch mismatch")
+	}
+
+	epochFullID := epochHandler.Hash + "#" + strconv.Itoa(epochHandler.Id)
+
+	if firstBlock.Epoch != epochFullID || secondBlock.Epoch != epochFullID {
+		return errors.New("blocks are not from evidence epoch")
+	}
+
+	if firstBlock.Creator != secondBlock.Creator || firstBlock.Index != secondBlock.Index {
+		return errors.New("blocks have different ids")
+	}
+
+	if evidence.BlockId != strconv.Itoa(epochHandler.Id)+":"+firstBlock.Creator+":"+strconv.Itoa(firstBlock.Index) {
+		return errors.New("evidence blockId mismatch")
+	}
+
+	if !slices.Contains(epochHandler.AnchorsRegistry, firstBlock.Creator) {
+		return errors.New("creator is not part of epoch")
+	}
+
+	if firstBlock.GetHash() == secondBlock.GetHash() {
+		return errors.New("blocks are equal")
+	}
+
+	if !firstBlock.VerifySignature() || !secondBlock.VerifySignature() {
+		return errors.New("invalid block signature")
+	}
+
+	return nil
+
+}
@@ -4,23 +4,34 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/modulrcloud/modulr-anchors-core/globals"
 	"github.com/modulrcloud/modulr-anchors-core/structures"
 )
 
 type ExtraDataToBlock struct {
 	AggregatedAnchorRotationProofs     []structures.AggregatedAnchorRotationProof     `json:"aggregatedAnchorRotationProofs,omitempty"`
 	AggregatedLeaderFinalizationProofs []structures.AggregatedLeaderFinalizationProof `json:"aggregatedLeaderFinalizationProofs,omitempty"`
+	AnchorMembershipRequests           []structures.AnchorMembershipRequest           `json:"anchorMembershipRequests,omitempty"`
+	AggregatedNetworkParametersProofs  []structures.AggregatedNetworkParametersProof  `json:"aggregatedNetworkParametersProofs,omitempty"`
+	BlockEquivocationEvidences         []structures.BlockEquivocationEvidence         `json:"blockEquivocationEvidences,omitempty"`
+	DoubleVoteEvidences                []structures.DoubleVoteEvidence                `json:"doubleVoteEvidences,omitempty"`
+	Transactions                       []structures.AnchorTransaction                 `json:"transactions,omitempty"`
 	Rest                               map[string]string                              `json:"rest,omitempty"`
 }
 
 type blockExtraDataAlias struct {
 	AggregatedAnchorRotationProofs     []structures.AggregatedAnchorRotationProof     `json:"aggregatedAnchorRotationProofs,omitempty"`
 	AggregatedLeaderFinalizationProofs []structures.AggregatedLeaderFinalizationProof `json:"aggregatedLeaderFinalizationProofs,omitempty"`
+	AnchorMembershipRequests           []structures.AnchorMembershipRequest           `json:"anchorMembershipRequests,omitempty"`
+	AggregatedNetworkParametersProofs  []structures.AggregatedNetworkParametersProof  `json:"aggregatedNetworkParametersProofs,omitempty"`
+	BlockEquivocationEvidences         []structures.BlockEquivocationEvidence         `json:"blockEquivocationEvidences,omitempty"`
+	DoubleVoteEvidences                []structures.DoubleVoteEvidence                `json:"doubleVoteEvidences,omitempty"`
+	Transactions                       []structures.AnchorTransaction                 `json:"transactions,omitempty"`
 	Rest                               map[string]string                              `json:"rest,omitempty"`
 }
 
 func (extra ExtraDataToBlock) MarshalJSON() ([]byte, error) {
-	if len(extra.AggregatedAnchorRotationProofs) == 0 && len(extra.AggregatedLeaderFinalizationProofs) == 0 {
+	if len(extra.AggregatedAnchorRotationProofs) == 0 && len(extra.AggregatedLeaderFinalizationProofs) == 0 && len(extra.AnchorMembershipRequests) == 0 && len(extra.AggregatedNetworkParametersProofs) == 0 && len(extra.BlockEquivocationEvidences) == 0 && len(extra.DoubleVoteEvidences) == 0 && len(extra.Transactions) == 0 {
 		if len(extra.Rest) == 0 {
 			return []byte("{}"), nil
 		}
@@ -39,7 +50,7 @@ func (extra *ExtraDataToBlock) UnmarshalJSON(data []byte) error {
 		return nil
 	}
 	var alias blockExtraDataAlias
-	if err := json.Unmarshal(data, &alias); err == nil && (alias.Rest != nil || alias.AggregatedAnchorRotationProofs != nil || alias.AggregatedLeaderFinalizationProofs != nil) {
+	if err := json.Unmarshal(data, &alias); err == nil && (alias.Rest != nil || alias.AggregatedAnchorRotationProofs != nil || alias.AggregatedLeaderFinalizationProofs != nil || alias.AnchorMembershipRequests != nil || alias.AggregatedNetworkParametersProofs != nil || alias.BlockEquivocationEvidences != nil || alias.DoubleVoteEvidences != nil || alias.Transactions != nil) {
 		*extra = ExtraDataToBlock(alias)
 		return nil
 	}
@@ -48,7 +59,39 @@ func (extra *ExtraDataToBlock) UnmarshalJSON(data []byte) error {
 		extra.Rest = fields
 		extra.AggregatedAnchorRotationProofs = nil
 		extra.AggregatedLeaderFinalizationProofs = nil
+		extra.AnchorMembershipRequests = nil
+		extra.AggregatedNetworkParametersProofs = nil
+		extra.BlockEquivocationEvidences = nil
+		extra.DoubleVoteEvidences = nil
+		extra.Transactions = nil
 		return nil
 	}
 	return fmt.Errorf("invalid extraData payload")
 }
+
+// MempoolKeys returns the keys under which items of extra data are stored in mempool
+func (extra *ExtraDataToBlock) MempoolKeys() []string {
+	keys := make([]string, 0, extra.ItemsCount())
+	for _, proof := range extra.AggregatedAnchorRotationProofs {
+		keys = append(keys, globals.AggregatedAnchorRotationProofMempoolKey(proof))
+	}
+	for _, proof := range extra.AggregatedLeaderFinalizationProofs {
+		keys = append(keys, globals.AggregatedLeaderFinalizationProofMempoolKey(proof))
+	}
+	for _, request := range extra.AnchorMembershipRequests {
+		keys = append(keys, globals.AnchorMembershipRequestMempoolKey(request))
+	}
+	for _, proof := range extra.AggregatedNetworkParametersProofs {
+		keys = append(keys, globals.AggregatedNetworkParametersProofMempoolKey(proof))
+	}
+	for _, evidence := range extra.BlockEquivocationEvidences {
+		keys = append(keys, globals.BlockEquivocationEvidenceMempoolKey(evidence))
+	}
+	for _, evidence := range extra.DoubleVoteEvidences {
+		keys = append(keys, globals.DoubleVoteEvidenceMempoolKey(evidence))
+	}
+	for _, tx := range extra.Transactions {
+		keys = append(keys, globals.AnchorTransactionMempoolKey(tx))
+	}
+	return keys
+}
@@ -0,0 +1,68 @@
+package block_pack
+
+import (
+	"encoding/json"
+
+	"github.com/modulrcloud/modulr-anchors-core/databases"
+	"github.com/modulrcloud/modulr-anchors-core/globals"
+	"github.com/modulrcloud/modulr-anchors-core/utils"
+)
+
+// LoadBlock reads the block stored by id (format epochIndex:creator:index)
+func LoadBlock(blockId string) (*Block, error) {
+
+	raw, err := databases.BLOCKS.Get([]byte(blockId))
+
+	if err != nil {
+		return nil, err
+	}
+
+	var block Block
+
+	if err := json.Unmarshal(raw, &block); err != nil {
+		return nil, err
+	}
+
+	return &block, nil
+
+}
+
+// ProcessFinalizedBlock adds records for payload of block which received AFP to batch.
+// Caller commits the batch together with the AFP, so payload is processed exactly when AFP is stored
+func ProcessFinalizedBlock(block *Block, epochIndex int, batch *databases.AtomicBatch) error {
+
+	if err := utils.StoreFinalizedAnchorMembershipRequests(epochIndex, block.ExtraData.AnchorMembershipRequests, batch); err != nil {
+		return err
+	}
+
+	if err := utils.StoreFinalizedNetworkParametersProofs(epochIndex, block.ExtraData.AggregatedNetworkParametersProofs, batch); err != nil {
+		return err
+	}
+
+	for _, evidence := range block.ExtraData.BlockEquivocationEvidences {
+
+		if err := utils.StoreBlockEquivocationEvidence(evidence, batch); err != nil {
+			return err
+		}
+
+	}
+
+	for _, evidence := range block.ExtraData.DoubleVoteEvidences {
+
+		if err := utils.StoreDoubleVoteEvidence(evidence, batch); err != nil {
+			return err
+		}
+
+	}
+
+	utils.StoreFinalizedTransactionNonces(block.ExtraData.Transactions, batch)
+
+	// Proofs of our finalized blocks are not included again
+
+	if block.Creator == globals.CONFIGURATION.PublicKey {
+		utils.StoreProofInclusions(block.ExtraData.AggregatedAnchorRotationProofs, block.ExtraData.AggregatedLeaderFinalizationProofs, batch)
+	}
+
+	return nil
+
+}
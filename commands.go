@@ -0,0 +1,305 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/modulrcloud/modulr-anchors-core/block_pack"
+	"github.com/modulrcloud/modulr-anchors-core/cryptography"
+	"github.com/modulrcloud/modulr-anchors-core/databases"
+	"github.com/modulrcloud/modulr-anchors-core/globals"
+	"github.com/modulrcloud/modulr-anchors-core/structures"
+	"github.com/modulrcloud/modulr-anchors-core/utils"
+)
+
+const commandsUsage = `Usage:
+  modulr-anchor                                         run the node
+  modulr-anchor slashing-protection export <file>       export journal of issued signatures
+  modulr-anchor slashing-protection import <file>       merge journal exported on another machine
+  modulr-anchor migrate [--dry-run]                     apply (or only list) pending chaindata migrations
+  modulr-anchor snapshot export <file>                  write consistent snapshot of chaindata to archive
+  modulr-anchor snapshot import <file> [--trust-pruned]  restore snapshot to empty CHAINDATA_PATH
+  modulr-anchor bls keygen                              generate BLS key for networks with BLS finalization proofs`
+
+// runCommand executes maintenance command instead of running the node. Node should be stopped, because
+// databases can't be opened by two processes. Returns exit code
+func runCommand(args []string) int {
+
+	switch args[0] {
+
+	case "migrate":
+
+		if len(args) == 1 {
+			return reportCommandResult(migrateChaindata(false))
+		}
+
+		if len(args) == 2 && args[1] == "--dry-run" {
+			return reportCommandResult(migrateChaindata(true))
+		}
+
+	case "slashing-protection":
+
+		if len(args) != 3 {
+			break
+		}
+
+		switch args[1] {
+
+		case "export":
+			return reportCommandResult(exportSlashingProtection(args[2]))
+
+		case "import":
+			return reportCommandResult(importSlashingProtection(args[2]))
+
+		}
+
+	case "bls":
+
+		if len(args) == 2 && args[1] == "keygen" {
+			return reportCommandResult(generateBlsKey())
+		}
+
+	case "snapshot":
+
+		if len(args) == 4 && args[1] == "import" && args[3] == "--trust-pruned" {
+			return reportCommandResult(importChaindataSnapshot(args[2], true))
+		}
+
+		if len(args) != 3 {
+			break
+		}
+
+		switch args[1] {
+
+		case "export":
+			return reportCommandResult(exportChaindataSnapshot(args[2]))
+
+		case "import":
+			return reportCommandResult(importChaindataSnapshot(args[2], false))
+
+		}
+
+	}
+
+	fmt.Println(commandsUsage)
+
+	return 2
+
+}
+
+func reportCommandResult(err error) int {
+
+	if err != nil {
+		utils.LogWithTime(err.Error(), utils.RED_COLOR)
+		return 1
+	}
+
+	return 0
+
+}
+
+func openSlashingProtection() error {
+
+	return databases.OpenSlashingProtection(globals.CHAINDATA_PATH + "/SLASHING_PROTECTION")
+
+}
+
+func exportSlashingProtection(path string) error {
+
+	if err := openSlashingProtection(); err != nil {
+		return err
+	}
+
+	defer databases.CloseAll()
+
+	interchange, err := utils.ExportSlashingProtection()
+
+	if err != nil {
+		return fmt.Errorf("export slashing protection journal: %w", err)
+	}
+
+	payload, err := json.MarshalIndent(interchange, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, payload, 0600); err != nil {
+		return err
+	}
+
+	utils.LogWithTime(fmt.Sprintf("Exported %d entries to %s", len(interchange.Entries), path), utils.GREEN_COLOR)
+
+	return nil
+
+}
+
+func importSlashingProtection(path string) error {
+
+	payload, err := os.ReadFile(path)
+
+	if err != nil {
+		return err
+	}
+
+	var interchange structures.SlashingProtectionInterchange
+
+	if err := json.Unmarshal(payload, &interchange); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	if err := openSlashingProtection(); err != nil {
+		return err
+	}
+
+	defer databases.CloseAll()
+
+	imported, conflicts, err := utils.ImportSlashingProtection(&interchange)
+
+	if err != nil {
+		return fmt.Errorf("import slashing protection journal: %w", err)
+	}
+
+	utils.LogWithTime(fmt.Sprintf("Imported %d entries from %s, %d entries already existed with another hash", imported, path, conflicts), utils.GREEN_COLOR)
+
+	return nil
+
+}
+
+func migrateChaindata(dryRun bool) error {
+
+	databasesDir := globals.CHAINDATA_PATH + "/DATABASES"
+
+	// Stores of the old layout are moved to one LevelDB on open, so dry-run stops before it
+
+	if legacyStores := databases.LegacyStores(databasesDir); dryRun && len(legacyStores) > 0 {
+		utils.LogWithTime(fmt.Sprintf("[dry-run] Stores %v will be moved to %s, then schema 0 migrations will be applied", legacyStores, databases.ROOT_STORE_NAME), utils.YELLOW_COLOR)
+		return nil
+	}
+
+	if err := openDatabases(databasesDir); err != nil {
+		return err
+	}
+
+	defer databases.CloseAll()
+
+	version, err := utils.ReadChaindataSchemaVersion()
+
+	if err != nil {
+		return err
+	}
+
+	utils.LogWithTime(fmt.Sprintf("Chaindata schema version %d, node supports %d", version, utils.CHAINDATA_SCHEMA_VERSION), utils.CYAN_COLOR)
+
+	return utils.MigrateChaindata(dryRun)
+
+}
+
+func exportChaindataSnapshot(path string) error {
+
+	if err := openDatabases(globals.CHAINDATA_PATH + "/DATABASES"); err != nil {
+		return err
+	}
+
+	defer databases.CloseAll()
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+
+	if err != nil {
+		return err
+	}
+
+	header, err := utils.ExportChaindataSnapshot(file)
+
+	if err == nil {
+		err = file.Sync()
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		os.Remove(path)
+		return fmt.Errorf("export snapshot: %w", err)
+	}
+
+	utils.LogWithTime(fmt.Sprintf("Exported %d records and %d epoch handlers to %s", header.Records, len(header.EpochHandlers), path), utils.GREEN_COLOR)
+
+	return nil
+
+}
+
+func importChaindataSnapshot(path string, trustPruned bool) error {
+
+	databasesDir := globals.CHAINDATA_PATH + "/DATABASES"
+
+	// Snapshot is never merged with existing chaindata
+
+	if entries, err := os.ReadDir(databasesDir); err == nil && len(entries) > 0 {
+		return fmt.Errorf("%s is not empty, snapshot can be imported only to empty CHAINDATA_PATH", databasesDir)
+	}
+
+	if err := openDatabases(databasesDir); err != nil {
+		return err
+	}
+
+	header, restored, err := utils.ImportChaindataSnapshot(path)
+
+	// Epoch handlers of the header are trusted only if they are derived from the local genesis
+
+	prunedEpoch := -1
+
+	if err == nil {
+		prunedEpoch, err = block_pack.VerifyEpochHandlersChain(header.EpochHandlers, trustPruned)
+	}
+
+	if err == nil {
+		err = utils.SetPrunedEpoch(prunedEpoch)
+	}
+
+	if closeErr := databases.CloseAll(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		os.RemoveAll(filepath.Join(databasesDir, databases.ROOT_STORE_NAME))
+		return fmt.Errorf("import snapshot: %w", err)
+	}
+
+	if prunedEpoch >= 0 {
+		utils.LogWithTime(fmt.Sprintf("Epochs up to %d are pruned in snapshot, later handlers are trusted by --trust-pruned", prunedEpoch), utils.YELLOW_COLOR)
+	}
+
+	utils.LogWithTime(fmt.Sprintf("Imported %d records of network %s exported by %s", restored, header.NetworkId, header.ExporterPubkey), utils.GREEN_COLOR)
+
+	return nil
+
+}
+
+// generateBlsKey prints new BLS private key for configs and pubkey with proof of possession for genesis or join request
+func generateBlsKey() error {
+
+	blsKeys, err := cryptography.GenerateRandomBlsKeyPair()
+
+	if err != nil {
+		return fmt.Errorf("generate BLS key: %w", err)
+	}
+
+	payload, err := json.MarshalIndent(map[string]string{
+		"BLS_PRIVATE_KEY":      blsKeys.Prv,
+		"blsPubkey":            blsKeys.Pub,
+		"blsProofOfPossession": blsKeys.ProofOfPossession,
+	}, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(payload))
+
+	return nil
+
+}
@@ -0,0 +1,218 @@
+package cryptography
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/cloudflare/circl/ecc/bls12381"
+	"github.com/cloudflare/circl/sign/bls"
+)
+
+// BLS12-381 keys live in G1 (48 bytes compressed) and signatures in G2 (96 bytes compressed).
+// Same as for ed25519 - pubkeys are base58 encoded, private keys and signatures are base64 encoded
+
+type BlsBox struct {
+	Pub, Prv, ProofOfPossession string
+}
+
+const blsKeyGenSalt = "MODULR_ANCHORS_BLS_KEYGEN"
+
+// Proof of possession is a signature of the pubkey itself. It's required for each registered BLS key
+// to prevent rogue key attacks on aggregated signatures over the same message
+const blsProofOfPossessionPrefix = "MODULR_ANCHORS:BLS_PROOF_OF_POSSESSION:"
+
+// GenerateBlsKeyPair derives BLS keypair from the input key material (at least 32 bytes)
+func GenerateBlsKeyPair(ikm []byte) (BlsBox, error) {
+
+	privateKey, err := bls.KeyGen[bls.KeyG1SigG2](ikm, []byte(blsKeyGenSalt), nil)
+
+	if err != nil {
+		return BlsBox{}, err
+	}
+
+	privKeyBytes, err := privateKey.MarshalBinary()
+
+	if err != nil {
+		return BlsBox{}, err
+	}
+
+	pubKeyBytes, err := privateKey.PublicKey().MarshalBinary()
+
+	if err != nil {
+		return BlsBox{}, err
+	}
+
+	box := BlsBox{Pub: base58.Encode(pubKeyBytes), Prv: base64.StdEncoding.EncodeToString(privKeyBytes)}
+
+	box.ProofOfPossession = GenerateBlsSignature(box.Prv, blsProofOfPossessionPrefix+box.Pub)
+
+	return box, nil
+
+}
+
+// GenerateRandomBlsKeyPair generates BLS keypair independent from any other key of node
+func GenerateRandomBlsKeyPair() (BlsBox, error) {
+
+	ikm := make([]byte, 32)
+
+	if _, err := rand.Read(ikm); err != nil {
+		return BlsBox{}, err
+	}
+
+	return GenerateBlsKeyPair(ikm)
+
+}
+
+// BlsKeyPairFromPrivateKey restores pubkey and proof of possession of the base64 encoded BLS private key
+func BlsKeyPairFromPrivateKey(base64PrivateKey string) (BlsBox, error) {
+
+	privateKeyAsBytes, err := base64.StdEncoding.DecodeString(base64PrivateKey)
+
+	if err != nil {
+		return BlsBox{}, err
+	}
+
+	var privateKey bls.PrivateKey[bls.KeyG1SigG2]
+
+	if err := privateKey.UnmarshalBinary(privateKeyAsBytes); err != nil {
+		return BlsBox{}, err
+	}
+
+	pubKeyBytes, err := privateKey.PublicKey().MarshalBinary()
+
+	if err != nil {
+		return BlsBox{}, err
+	}
+
+	box := BlsBox{Pub: base58.Encode(pubKeyBytes), Prv: base64PrivateKey}
+
+	box.ProofOfPossession = GenerateBlsSignature(box.Prv, blsProofOfPossessionPrefix+box.Pub)
+
+	return box, nil
+
+}
+
+func GenerateBlsSignature(base64PrivateKey, msg string) string {
+
+	privateKeyAsBytes, _ := base64.StdEncoding.DecodeString(base64PrivateKey)
+
+	var privateKey bls.PrivateKey[bls.KeyG1SigG2]
+
+	if err := privateKey.UnmarshalBinary(privateKeyAsBytes); err != nil {
+		return ""
+	}
+
+	return base64.StdEncoding.EncodeToString(bls.Sign(&privateKey, []byte(msg)))
+
+}
+
+func VerifyBlsSignature(message, base58PubKey, base64Signature string) bool {
+
+	publicKey, err := parseBlsPublicKey(base58PubKey)
+
+	if err != nil {
+		return false
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(base64Signature)
+
+	if err != nil {
+		return false
+	}
+
+	return bls.Verify(publicKey, []byte(message), signature)
+
+}
+
+func VerifyBlsProofOfPossession(base58PubKey, base64Proof string) bool {
+
+	return VerifyBlsSignature(blsProofOfPossessionPrefix+base58PubKey, base58PubKey, base64Proof)
+
+}
+
+// AggregateBlsSignatures sums signatures into the single one
+func AggregateBlsSignatures(base64Signatures []string) (string, error) {
+
+	signatures := make([]bls.Signature, 0, len(base64Signatures))
+
+	for _, base64Signature := range base64Signatures {
+
+		signature, err := base64.StdEncoding.DecodeString(base64Signature)
+
+		if err != nil {
+			return "", err
+		}
+
+		signatures = append(signatures, signature)
+
+	}
+
+	aggregated, err := bls.Aggregate(bls.KeyG1SigG2{}, signatures)
+
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(aggregated), nil
+
+}
+
+// VerifyBlsAggregatedSignature checks the aggregated signature of several signers over the same message.
+// Pubkeys are summed, so only keys with verified proof of possession should be passed here
+func VerifyBlsAggregatedSignature(message string, base58PubKeys []string, base64AggregatedSignature string) bool {
+
+	if len(base58PubKeys) == 0 {
+		return false
+	}
+
+	var aggregatedPoint bls12381.G1
+
+	aggregatedPoint.SetIdentity()
+
+	for _, base58PubKey := range base58PubKeys {
+
+		var point bls12381.G1
+
+		if err := point.SetBytes(base58.Decode(base58PubKey)); err != nil {
+			return false
+		}
+
+		aggregatedPoint.Add(&aggregatedPoint, &point)
+
+	}
+
+	var aggregatedPublicKey bls.PublicKey[bls.KeyG1SigG2]
+
+	if err := aggregatedPublicKey.UnmarshalBinary(aggregatedPoint.BytesCompressed()); err != nil {
+		return false
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(base64AggregatedSignature)
+
+	if err != nil {
+		return false
+	}
+
+	return bls.Verify(&aggregatedPublicKey, []byte(message), signature)
+
+}
+
+func parseBlsPublicKey(base58PubKey string) (*bls.PublicKey[bls.KeyG1SigG2], error) {
+
+	publicKeyAsBytes := base58.Decode(base58PubKey)
+
+	if len(publicKeyAsBytes) == 0 {
+		return nil, errors.New("invalid BLS pubkey encoding")
+	}
+
+	var publicKey bls.PublicKey[bls.KeyG1SigG2]
+
+	if err := publicKey.UnmarshalBinary(publicKeyAsBytes); err != nil {
+		return nil, err
+	}
+
+	return &publicKey, nil
+
+}
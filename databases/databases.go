@@ -3,42 +3,98 @@ package databases
 import (
 	"errors"
 	"fmt"
-
-	"github.com/syndtr/goleveldb/leveldb"
+	"path/filepath"
 )
 
-var BLOCKS, EPOCH_DATA, APPROVEMENT_THREAD_METADATA, FINALIZATION_VOTING_STATS *leveldb.DB
+var BLOCKS, EPOCH_DATA, APPROVEMENT_THREAD_METADATA, FINALIZATION_VOTING_STATS KVStore
 
-// CloseAll safely closes all initialized LevelDB instances
-func CloseAll() error {
+// Root store which holds all the namespaces above
+var ROOT KVStore
+
+// Journal of signatures issued by node. It's kept apart from ROOT, so restore of chain data never rolls it back
+var SLASHING_PROTECTION KVStore
+
+// Name of the directory (inside DATABASES) with the root LevelDB
+const ROOT_STORE_NAME = "STORE"
+
+var NAMESPACES = []string{"BLOCKS", "EPOCH_DATA", "APPROVEMENT_THREAD_METADATA", "FINALIZATION_VOTING_STATS"}
+
+// OpenAll opens root LevelDB in databasesDir and sets the namespaces. Data of the old layout
+// (one LevelDB per store) is moved to the root store first, moved stores are returned
+func OpenAll(databasesDir string) ([]MigratedStore, error) {
+
+	rootPath := filepath.Join(databasesDir, ROOT_STORE_NAME)
+
+	migrated, err := migrateLegacyLayout(databasesDir, rootPath)
+
+	if err != nil {
+		return migrated, fmt.Errorf("migrate legacy databases: %w", err)
+	}
 
-	type namedDB struct {
-		name string
-		db   **leveldb.DB
+	root, err := OpenLevelDb(rootPath)
+
+	if err != nil {
+		return migrated, fmt.Errorf("open %s: %w", rootPath, err)
 	}
 
-	databases := []namedDB{
-		{name: "BLOCKS", db: &BLOCKS},
-		{name: "EPOCH_DATA", db: &EPOCH_DATA},
-		{name: "APPROVEMENT_THREAD_METADATA", db: &APPROVEMENT_THREAD_METADATA},
-		{name: "FINALIZATION_VOTING_STATS", db: &FINALIZATION_VOTING_STATS},
+	useRoot(root)
+
+	return migrated, nil
+
+}
+
+// OpenSlashingProtection opens the journal of issued signatures. All its writes are synced to disk
+func OpenSlashingProtection(path string) error {
+
+	journal, err := OpenSyncedLevelDb(path)
+
+	if err != nil {
+		return fmt.Errorf("open %s: %w", path, err)
 	}
 
+	SLASHING_PROTECTION = journal
+
+	return nil
+
+}
+
+// UseMemoryStores replaces all the stores with in-memory ones (e.g. to run threads or routes in tests)
+func UseMemoryStores() {
+
+	useRoot(NewMemoryStore())
+
+	SLASHING_PROTECTION = NewMemoryStore()
+
+}
+
+func useRoot(root KVStore) {
+
+	ROOT = root
+
+	BLOCKS = NewNamespace(root, "BLOCKS")
+	EPOCH_DATA = NewNamespace(root, "EPOCH_DATA")
+	APPROVEMENT_THREAD_METADATA = NewNamespace(root, "APPROVEMENT_THREAD_METADATA")
+	FINALIZATION_VOTING_STATS = NewNamespace(root, "FINALIZATION_VOTING_STATS")
+
+}
+
+// CloseAll safely closes the root store and the slashing protection journal
+func CloseAll() error {
+
 	var errs []error
-	for _, database := range databases {
-		if database.db == nil || *database.db == nil {
-			continue
-		}
 
-		if err := (*database.db).Close(); err != nil {
-			errs = append(errs, fmt.Errorf("close %s: %w", database.name, err))
+	if ROOT != nil {
+		if err := ROOT.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close %s: %w", ROOT_STORE_NAME, err))
 		}
-
 	}
 
-	if len(errs) > 0 {
-		return errors.Join(errs...)
+	if SLASHING_PROTECTION != nil {
+		if err := SLASHING_PROTECTION.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close SLASHING_PROTECTION: %w", err))
+		}
 	}
 
-	return nil
+	return errors.Join(errs...)
+
 }
@@ -0,0 +1,74 @@
+package databases
+
+import (
+	ldbErrors "github.com/syndtr/goleveldb/leveldb/errors"
+)
+
+// ErrNotFound is returned by Get when key doesn't exist. All backends return this error
+var ErrNotFound = ldbErrors.ErrNotFound
+
+// Reader is the read part of key-value store, shared by stores and their snapshots
+type Reader interface {
+	Get(key []byte) ([]byte, error)
+	Has(key []byte) (bool, error)
+	// NewIterator iterates over keys with prefix in ascending order. Empty prefix iterates over the whole store
+	NewIterator(prefix []byte) Iterator
+}
+
+// KVStore is the key-value storage used by node. Implementations should be safe for concurrent use
+type KVStore interface {
+	Reader
+	Put(key, value []byte) error
+	Delete(key []byte) error
+	// Write applies all operations of batch atomically
+	Write(batch *Batch) error
+	// NewSnapshot returns consistent read-only view of the current state
+	NewSnapshot() (Snapshot, error)
+	Close() error
+}
+
+type Snapshot interface {
+	Reader
+	Release()
+}
+
+type Iterator interface {
+	Next() bool
+	Key() []byte
+	Value() []byte
+	Release()
+	Error() error
+}
+
+type batchOperation struct {
+	key, value []byte
+	delete     bool
+}
+
+// Batch collects puts and deletes to be applied atomically by KVStore.Write
+type Batch struct {
+	operations []batchOperation
+}
+
+func (batch *Batch) Put(key, value []byte) {
+	batch.operations = append(batch.operations, batchOperation{key: cloneBytes(key), value: cloneBytes(value)})
+}
+
+func (batch *Batch) Delete(key []byte) {
+	batch.operations = append(batch.operations, batchOperation{key: cloneBytes(key), delete: true})
+}
+
+func (batch *Batch) Len() int {
+	return len(batch.operations)
+}
+
+func (batch *Batch) Reset() {
+	batch.operations = batch.operations[:0]
+}
+
+func cloneBytes(data []byte) []byte {
+	if data == nil {
+		return nil
+	}
+	return append([]byte{}, data...)
+}
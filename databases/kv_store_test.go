@@ -0,0 +1,276 @@
+package databases
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// Contract of KVStore which every backend (and namespace over it) should follow
+
+func storeBackends(t *testing.T) map[string]func(t *testing.T) KVStore {
+
+	return map[string]func(t *testing.T) KVStore{
+
+		"memory": func(t *testing.T) KVStore {
+			return NewMemoryStore()
+		},
+
+		"leveldb": func(t *testing.T) KVStore {
+
+			store, err := OpenLevelDb(filepath.Join(t.TempDir(), "STORE"))
+
+			if err != nil {
+				t.Fatalf("open leveldb: %v", err)
+			}
+
+			t.Cleanup(func() { store.Close() })
+
+			return store
+
+		},
+
+		"namespace over memory": func(t *testing.T) KVStore {
+			return NewNamespace(NewMemoryStore(), "BLOCKS")
+		},
+	}
+
+}
+
+func runStoreContract(t *testing.T, test func(t *testing.T, store KVStore)) {
+
+	for name, open := range storeBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			test(t, open(t))
+		})
+	}
+
+}
+
+func mustPut(t *testing.T, store KVStore, key, value string) {
+
+	if err := store.Put([]byte(key), []byte(value)); err != nil {
+		t.Fatalf("put %s: %v", key, err)
+	}
+
+}
+
+func collectKeys(iterator Iterator) []string {
+
+	defer iterator.Release()
+
+	keys := []string{}
+
+	for iterator.Next() {
+		keys = append(keys, string(iterator.Key()))
+	}
+
+	return keys
+
+}
+
+func TestStoreGetPutDelete(t *testing.T) {
+
+	runStoreContract(t, func(t *testing.T, store KVStore) {
+
+		if _, err := store.Get([]byte("missing")); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("get of missing key: expected ErrNotFound, got %v", err)
+		}
+
+		mustPut(t, store, "key", "value")
+
+		value, err := store.Get([]byte("key"))
+
+		if err != nil || string(value) != "value" {
+			t.Fatalf("get: %q, %v", value, err)
+		}
+
+		if has, err := store.Has([]byte("key")); err != nil || !has {
+			t.Fatalf("has: %v, %v", has, err)
+		}
+
+		if err := store.Delete([]byte("key")); err != nil {
+			t.Fatalf("delete: %v", err)
+		}
+
+		if has, _ := store.Has([]byte("key")); has {
+			t.Fatal("key exists after delete")
+		}
+
+	})
+
+}
+
+func TestStoreReturnsCopies(t *testing.T) {
+
+	runStoreContract(t, func(t *testing.T, store KVStore) {
+
+		value := []byte("value")
+
+		if err := store.Put([]byte("key"), value); err != nil {
+			t.Fatalf("put: %v", err)
+		}
+
+		value[0] = 'X'
+
+		stored, _ := store.Get([]byte("key"))
+
+		if string(stored) != "value" {
+			t.Fatalf("store was changed through the slice passed to Put: %q", stored)
+		}
+
+	})
+
+}
+
+func TestStoreIteratorPrefixAndOrder(t *testing.T) {
+
+	runStoreContract(t, func(t *testing.T, store KVStore) {
+
+		for _, key := range []string{"1:b", "10:a", "1:a", "2:a"} {
+			mustPut(t, store, key, "v")
+		}
+
+		keys := collectKeys(store.NewIterator([]byte("1:")))
+
+		if len(keys) != 2 || keys[0] != "1:a" || keys[1] != "1:b" {
+			t.Fatalf("prefix 1: should give [1:a 1:b] in order, got %v", keys)
+		}
+
+		if all := collectKeys(store.NewIterator(nil)); len(all) != 4 || all[0] != "10:a" || all[3] != "2:a" {
+			t.Fatalf("empty prefix should iterate over the whole store in order, got %v", all)
+		}
+
+	})
+
+}
+
+func TestStoreIteratorIgnoresLaterWrites(t *testing.T) {
+
+	runStoreContract(t, func(t *testing.T, store KVStore) {
+
+		mustPut(t, store, "a", "1")
+		mustPut(t, store, "b", "1")
+
+		iterator := store.NewIterator(nil)
+
+		store.Delete([]byte("b"))
+
+		mustPut(t, store, "c", "1")
+
+		if keys := collectKeys(iterator); len(keys) != 2 || keys[1] != "b" {
+			t.Fatalf("iterator should see the state on its creation, got %v", keys)
+		}
+
+	})
+
+}
+
+func TestStoreBatch(t *testing.T) {
+
+	runStoreContract(t, func(t *testing.T, store KVStore) {
+
+		mustPut(t, store, "old", "1")
+
+		batch := new(Batch)
+
+		batch.Put([]byte("new"), []byte("2"))
+		batch.Delete([]byte("old"))
+		batch.Put([]byte("new"), []byte("3"))
+
+		if err := store.Write(batch); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+
+		if has, _ := store.Has([]byte("old")); has {
+			t.Fatal("deleted key exists after batch")
+		}
+
+		// Operations are applied in order, the last put wins
+
+		if value, _ := store.Get([]byte("new")); string(value) != "3" {
+			t.Fatalf("expected 3, got %q", value)
+		}
+
+	})
+
+}
+
+func TestStoreSnapshot(t *testing.T) {
+
+	runStoreContract(t, func(t *testing.T, store KVStore) {
+
+		mustPut(t, store, "key", "before")
+
+		snapshot, err := store.NewSnapshot()
+
+		if err != nil {
+			t.Fatalf("snapshot: %v", err)
+		}
+
+		defer snapshot.Release()
+
+		mustPut(t, store, "key", "after")
+		mustPut(t, store, "other", "after")
+
+		if value, _ := snapshot.Get([]byte("key")); string(value) != "before" {
+			t.Fatalf("snapshot should keep the old value, got %q", value)
+		}
+
+		if has, _ := snapshot.Has([]byte("other")); has {
+			t.Fatal("snapshot sees key written after it")
+		}
+
+		if keys := collectKeys(snapshot.NewIterator(nil)); len(keys) != 1 {
+			t.Fatalf("snapshot iterator should see 1 key, got %v", keys)
+		}
+
+	})
+
+}
+
+func TestNamespacesAreIsolatedAndAtomic(t *testing.T) {
+
+	for name, open := range storeBackends(t) {
+
+		if name == "namespace over memory" {
+			continue
+		}
+
+		t.Run(name, func(t *testing.T) {
+
+			root := open(t)
+
+			blocks, epochData := NewNamespace(root, "BLOCKS"), NewNamespace(root, "EPOCH_DATA")
+
+			mustPut(t, blocks, "key", "blocks")
+
+			if _, err := epochData.Get([]byte("key")); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("namespace sees key of another one: %v", err)
+			}
+
+			atomicBatch := NewAtomicBatch()
+
+			atomicBatch.Put(blocks, []byte("key"), []byte("updated"))
+			atomicBatch.Put(epochData, []byte("key"), []byte("epoch"))
+
+			if err := atomicBatch.Write(); err != nil {
+				t.Fatalf("atomic batch: %v", err)
+			}
+
+			rootValue, err := root.Get([]byte("BLOCKS/key"))
+
+			if err != nil || !bytes.Equal(rootValue, []byte("updated")) {
+				t.Fatalf("root should contain namespaced key: %q, %v", rootValue, err)
+			}
+
+			if keys := collectKeys(epochData.NewIterator(nil)); len(keys) != 1 || keys[0] != "key" {
+				t.Fatalf("namespace iterator should return keys without namespace, got %v", keys)
+			}
+
+		})
+
+	}
+
+}
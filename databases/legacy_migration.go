@@ -0,0 +1,128 @@
+package databases
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+)
+
+// Number of records copied to root store per batch during migration
+const MIGRATION_BATCH_SIZE = 10000
+
+// MigratedStore is a store of the old layout moved to the root store
+type MigratedStore struct {
+	Name    string
+	Records int
+}
+
+// migrateLegacyLayout copies each DATABASES/<NAME> LevelDB to namespace <NAME> of the root store and removes
+// the old directory. Copy is idempotent, so if node stops in the middle the migration is simply repeated on next start
+func migrateLegacyLayout(databasesDir, rootPath string) ([]MigratedStore, error) {
+
+	legacyNames := LegacyStores(databasesDir)
+
+	if len(legacyNames) == 0 {
+		return nil, nil
+	}
+
+	root, err := leveldb.OpenFile(rootPath, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer root.Close()
+
+	var migrated []MigratedStore
+
+	for _, name := range legacyNames {
+
+		legacyPath := filepath.Join(databasesDir, name)
+
+		copied, err := copyLegacyStore(legacyPath, root, []byte(name+NAMESPACE_SEPARATOR))
+
+		if err != nil {
+			return migrated, fmt.Errorf("%s: %w", name, err)
+		}
+
+		// Records are synced to disk, now the old directory can be removed
+
+		if err := os.RemoveAll(legacyPath); err != nil {
+			return migrated, fmt.Errorf("remove %s: %w", legacyPath, err)
+		}
+
+		migrated = append(migrated, MigratedStore{Name: name, Records: copied})
+
+	}
+
+	return migrated, nil
+
+}
+
+// LegacyStores returns names of stores which are still kept in separate directories of the old layout
+func LegacyStores(databasesDir string) []string {
+
+	var legacyNames []string
+
+	for _, name := range NAMESPACES {
+		if info, err := os.Stat(filepath.Join(databasesDir, name)); err == nil && info.IsDir() {
+			legacyNames = append(legacyNames, name)
+		}
+	}
+
+	return legacyNames
+
+}
+
+func copyLegacyStore(legacyPath string, root *leveldb.DB, prefix []byte) (int, error) {
+
+	legacy, err := leveldb.OpenFile(legacyPath, &opt.Options{ErrorIfMissing: true, ReadOnly: true})
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer legacy.Close()
+
+	iterator := legacy.NewIterator(nil, nil)
+
+	defer iterator.Release()
+
+	batch := new(leveldb.Batch)
+
+	copied := 0
+
+	for iterator.Next() {
+
+		batch.Put(append(append([]byte{}, prefix...), iterator.Key()...), iterator.Value())
+
+		copied++
+
+		if batch.Len() >= MIGRATION_BATCH_SIZE {
+
+			if err := root.Write(batch, nil); err != nil {
+				return copied, err
+			}
+
+			batch.Reset()
+
+		}
+
+	}
+
+	if err := iterator.Error(); err != nil {
+		return copied, err
+	}
+
+	// The last write is synced, so all the previous writes are on disk too
+
+	if err := root.Write(batch, &opt.WriteOptions{Sync: true}); err != nil {
+		return copied, err
+	}
+
+	return copied, nil
+
+}
@@ -0,0 +1,110 @@
+package databases
+
+import (
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+type levelDbStore struct {
+	db           *leveldb.DB
+	writeOptions *opt.WriteOptions
+}
+
+type levelDbSnapshot struct {
+	snapshot *leveldb.Snapshot
+}
+
+// OpenLevelDb opens (or creates) LevelDB store in the directory
+func OpenLevelDb(path string) (KVStore, error) {
+
+	db, err := leveldb.OpenFile(path, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &levelDbStore{db: db}, nil
+
+}
+
+// OpenSyncedLevelDb is the same as OpenLevelDb, but every write is synced to disk before it returns
+func OpenSyncedLevelDb(path string) (KVStore, error) {
+
+	db, err := leveldb.OpenFile(path, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &levelDbStore{db: db, writeOptions: &opt.WriteOptions{Sync: true}}, nil
+
+}
+
+func (store *levelDbStore) Get(key []byte) ([]byte, error) {
+	return store.db.Get(key, nil)
+}
+
+func (store *levelDbStore) Has(key []byte) (bool, error) {
+	return store.db.Has(key, nil)
+}
+
+func (store *levelDbStore) NewIterator(prefix []byte) Iterator {
+	return store.db.NewIterator(util.BytesPrefix(prefix), nil)
+}
+
+func (store *levelDbStore) Put(key, value []byte) error {
+	return store.db.Put(key, value, store.writeOptions)
+}
+
+func (store *levelDbStore) Delete(key []byte) error {
+	return store.db.Delete(key, store.writeOptions)
+}
+
+func (store *levelDbStore) Write(batch *Batch) error {
+
+	levelDbBatch := new(leveldb.Batch)
+
+	for _, operation := range batch.operations {
+		if operation.delete {
+			levelDbBatch.Delete(operation.key)
+		} else {
+			levelDbBatch.Put(operation.key, operation.value)
+		}
+	}
+
+	return store.db.Write(levelDbBatch, store.writeOptions)
+
+}
+
+func (store *levelDbStore) NewSnapshot() (Snapshot, error) {
+
+	snapshot, err := store.db.GetSnapshot()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &levelDbSnapshot{snapshot: snapshot}, nil
+
+}
+
+func (store *levelDbStore) Close() error {
+	return store.db.Close()
+}
+
+func (snapshot *levelDbSnapshot) Get(key []byte) ([]byte, error) {
+	return snapshot.snapshot.Get(key, nil)
+}
+
+func (snapshot *levelDbSnapshot) Has(key []byte) (bool, error) {
+	return snapshot.snapshot.Has(key, nil)
+}
+
+func (snapshot *levelDbSnapshot) NewIterator(prefix []byte) Iterator {
+	return snapshot.snapshot.NewIterator(util.BytesPrefix(prefix), nil)
+}
+
+func (snapshot *levelDbSnapshot) Release() {
+	snapshot.snapshot.Release()
+}
@@ -0,0 +1,244 @@
+package databases
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var errStoreIsClosed = errors.New("store is closed")
+
+// memoryStore keeps data in map. It's used to run threads and routes without disk
+type memoryStore struct {
+	sync.RWMutex
+	data   map[string][]byte
+	closed bool
+}
+
+// memoryReader is a frozen copy of data, used for snapshots
+type memoryReader struct {
+	data map[string][]byte
+}
+
+type memoryIterator struct {
+	keys   []string
+	values [][]byte
+	pos    int
+}
+
+func NewMemoryStore() KVStore {
+	return &memoryStore{data: make(map[string][]byte)}
+}
+
+func (store *memoryStore) Get(key []byte) ([]byte, error) {
+
+	store.RLock()
+	defer store.RUnlock()
+
+	if store.closed {
+		return nil, errStoreIsClosed
+	}
+
+	value, ok := store.data[string(key)]
+
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return cloneBytes(value), nil
+
+}
+
+func (store *memoryStore) Has(key []byte) (bool, error) {
+
+	store.RLock()
+	defer store.RUnlock()
+
+	if store.closed {
+		return false, errStoreIsClosed
+	}
+
+	_, ok := store.data[string(key)]
+
+	return ok, nil
+
+}
+
+func (store *memoryStore) NewIterator(prefix []byte) Iterator {
+
+	store.RLock()
+	defer store.RUnlock()
+
+	return newMemoryIterator(store.data, prefix)
+
+}
+
+func (store *memoryStore) Put(key, value []byte) error {
+
+	store.Lock()
+	defer store.Unlock()
+
+	if store.closed {
+		return errStoreIsClosed
+	}
+
+	store.data[string(key)] = cloneBytes(value)
+
+	return nil
+
+}
+
+func (store *memoryStore) Delete(key []byte) error {
+
+	store.Lock()
+	defer store.Unlock()
+
+	if store.closed {
+		return errStoreIsClosed
+	}
+
+	delete(store.data, string(key))
+
+	return nil
+
+}
+
+func (store *memoryStore) Write(batch *Batch) error {
+
+	store.Lock()
+	defer store.Unlock()
+
+	if store.closed {
+		return errStoreIsClosed
+	}
+
+	for _, operation := range batch.operations {
+		if operation.delete {
+			delete(store.data, string(operation.key))
+		} else {
+			store.data[string(operation.key)] = cloneBytes(operation.value)
+		}
+	}
+
+	return nil
+
+}
+
+func (store *memoryStore) NewSnapshot() (Snapshot, error) {
+
+	store.RLock()
+	defer store.RUnlock()
+
+	if store.closed {
+		return nil, errStoreIsClosed
+	}
+
+	frozen := make(map[string][]byte, len(store.data))
+
+	for key, value := range store.data {
+		frozen[key] = value
+	}
+
+	return &memoryReader{data: frozen}, nil
+
+}
+
+func (store *memoryStore) Close() error {
+
+	store.Lock()
+	defer store.Unlock()
+
+	store.closed = true
+
+	return nil
+
+}
+
+func (reader *memoryReader) Get(key []byte) ([]byte, error) {
+
+	value, ok := reader.data[string(key)]
+
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return cloneBytes(value), nil
+
+}
+
+func (reader *memoryReader) Has(key []byte) (bool, error) {
+
+	_, ok := reader.data[string(key)]
+
+	return ok, nil
+
+}
+
+func (reader *memoryReader) NewIterator(prefix []byte) Iterator {
+	return newMemoryIterator(reader.data, prefix)
+}
+
+func (reader *memoryReader) Release() {}
+
+// newMemoryIterator copies matching pairs, so iterator isn't affected by later writes (same as LevelDB iterators)
+func newMemoryIterator(data map[string][]byte, prefix []byte) *memoryIterator {
+
+	iterator := &memoryIterator{pos: -1}
+
+	for key := range data {
+		if strings.HasPrefix(key, string(prefix)) {
+			iterator.keys = append(iterator.keys, key)
+		}
+	}
+
+	sort.Strings(iterator.keys)
+
+	for _, key := range iterator.keys {
+		iterator.values = append(iterator.values, data[key])
+	}
+
+	return iterator
+
+}
+
+func (iterator *memoryIterator) Next() bool {
+
+	if iterator.pos+1 >= len(iterator.keys) {
+		iterator.pos = len(iterator.keys)
+		return false
+	}
+
+	iterator.pos++
+
+	return true
+
+}
+
+func (iterator *memoryIterator) Key() []byte {
+
+	if iterator.pos < 0 || iterator.pos >= len(iterator.keys) {
+		return nil
+	}
+
+	return []byte(iterator.keys[iterator.pos])
+
+}
+
+func (iterator *memoryIterator) Value() []byte {
+
+	if iterator.pos < 0 || iterator.pos >= len(iterator.values) {
+		return nil
+	}
+
+	return iterator.values[iterator.pos]
+
+}
+
+func (iterator *memoryIterator) Release() {
+	iterator.keys, iterator.values = nil, nil
+}
+
+func (iterator *memoryIterator) Error() error {
+	return nil
+}
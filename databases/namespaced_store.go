@@ -0,0 +1,187 @@
+package databases
+
+import "errors"
+
+// All the stores of node are namespaces of one root store. Key of namespace is stored as <NAME>/<key>,
+// so writes to several namespaces can be committed as one atomic batch (see AtomicBatch)
+
+const NAMESPACE_SEPARATOR = "/"
+
+type namespacedStore struct {
+	root   KVStore
+	prefix []byte
+}
+
+type namespacedSnapshot struct {
+	snapshot Snapshot
+	prefix   []byte
+}
+
+type namespacedIterator struct {
+	Iterator
+	prefixLength int
+}
+
+// NewNamespace returns view of root store where all keys are prefixed with name
+func NewNamespace(root KVStore, name string) KVStore {
+	return &namespacedStore{root: root, prefix: []byte(name + NAMESPACE_SEPARATOR)}
+}
+
+func (store *namespacedStore) key(key []byte) []byte {
+	return append(cloneBytes(store.prefix), key...)
+}
+
+func (store *namespacedStore) Get(key []byte) ([]byte, error) {
+	return store.root.Get(store.key(key))
+}
+
+func (store *namespacedStore) Has(key []byte) (bool, error) {
+	return store.root.Has(store.key(key))
+}
+
+func (store *namespacedStore) NewIterator(prefix []byte) Iterator {
+	return &namespacedIterator{Iterator: store.root.NewIterator(store.key(prefix)), prefixLength: len(store.prefix)}
+}
+
+func (store *namespacedStore) Put(key, value []byte) error {
+	return store.root.Put(store.key(key), value)
+}
+
+func (store *namespacedStore) Delete(key []byte) error {
+	return store.root.Delete(store.key(key))
+}
+
+func (store *namespacedStore) Write(batch *Batch) error {
+
+	rootBatch := new(Batch)
+
+	store.appendTo(rootBatch, batch)
+
+	return store.root.Write(rootBatch)
+
+}
+
+func (store *namespacedStore) appendTo(rootBatch, batch *Batch) {
+
+	for _, operation := range batch.operations {
+		rootBatch.operations = append(rootBatch.operations, batchOperation{key: store.key(operation.key), value: operation.value, delete: operation.delete})
+	}
+
+}
+
+func (store *namespacedStore) NewSnapshot() (Snapshot, error) {
+
+	snapshot, err := store.root.NewSnapshot()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &namespacedSnapshot{snapshot: snapshot, prefix: store.prefix}, nil
+
+}
+
+// Close does nothing, the root store is closed by CloseAll
+func (store *namespacedStore) Close() error {
+	return nil
+}
+
+func (snapshot *namespacedSnapshot) key(key []byte) []byte {
+	return append(cloneBytes(snapshot.prefix), key...)
+}
+
+func (snapshot *namespacedSnapshot) Get(key []byte) ([]byte, error) {
+	return snapshot.snapshot.Get(snapshot.key(key))
+}
+
+func (snapshot *namespacedSnapshot) Has(key []byte) (bool, error) {
+	return snapshot.snapshot.Has(snapshot.key(key))
+}
+
+func (snapshot *namespacedSnapshot) NewIterator(prefix []byte) Iterator {
+	return &namespacedIterator{Iterator: snapshot.snapshot.NewIterator(snapshot.key(prefix)), prefixLength: len(snapshot.prefix)}
+}
+
+func (snapshot *namespacedSnapshot) Release() {
+	snapshot.snapshot.Release()
+}
+
+func (iterator *namespacedIterator) Key() []byte {
+
+	key := iterator.Iterator.Key()
+
+	if len(key) < iterator.prefixLength {
+		return key
+	}
+
+	return key[iterator.prefixLength:]
+
+}
+
+var errNotSameRoot = errors.New("atomic batch spans stores which are not namespaces of the same root")
+
+// AtomicBatch collects puts and deletes to several stores and commits them as one batch of root store
+type AtomicBatch struct {
+	root  KVStore
+	batch Batch
+	err   error
+}
+
+func NewAtomicBatch() *AtomicBatch {
+	return &AtomicBatch{}
+}
+
+func (atomicBatch *AtomicBatch) Put(store KVStore, key, value []byte) {
+
+	single := new(Batch)
+
+	single.Put(key, value)
+
+	atomicBatch.Append(store, single)
+
+}
+
+func (atomicBatch *AtomicBatch) Delete(store KVStore, key []byte) {
+
+	single := new(Batch)
+
+	single.Delete(key)
+
+	atomicBatch.Append(store, single)
+
+}
+
+// Append adds all the operations of batch for store
+func (atomicBatch *AtomicBatch) Append(store KVStore, batch *Batch) {
+
+	namespace, ok := store.(*namespacedStore)
+
+	if !ok || atomicBatch.root != nil && atomicBatch.root != namespace.root {
+		atomicBatch.err = errNotSameRoot
+		return
+	}
+
+	atomicBatch.root = namespace.root
+
+	namespace.appendTo(&atomicBatch.batch, batch)
+
+}
+
+func (atomicBatch *AtomicBatch) Len() int {
+	return atomicBatch.batch.Len()
+}
+
+// Write commits all the collected operations. Nothing is written if batch has an error
+func (atomicBatch *AtomicBatch) Write() error {
+
+	if atomicBatch.err != nil {
+		return atomicBatch.err
+	}
+
+	if atomicBatch.root == nil {
+		return nil
+	}
+
+	return atomicBatch.root.Write(&atomicBatch.batch)
+
+}
@@ -14,8 +14,6 @@ import (
 	"github.com/modulrcloud/modulr-anchors-core/threads"
 	"github.com/modulrcloud/modulr-anchors-core/utils"
 	"github.com/modulrcloud/modulr-anchors-core/websocket_pack"
-
-	"github.com/syndtr/goleveldb/leveldb"
 )
 
 func RunAnchorsChains() {
@@ -47,6 +45,21 @@ func RunAnchorsChains() {
 	// ✅ 5.Collect anchor rotation proofs from quorum
 	go threads.AnchorRotationCollectorThread()
 
+	// ✅ 6.Collect epoch finish proofs for epochs whose time is over
+	go threads.EpochFinishCollectorThread()
+
+	// ✅ 7.Collect changes of registry and network parameters for the next epoch
+	go threads.EpochChangesCollectorThread()
+
+	// ✅ 8.Ask quorum to reinstate us if our blocks stopped receiving proofs
+	go threads.CreatorReinstatementThread()
+
+	// ✅ 9.Report requests and connections rejected by rate limits
+	go threads.RateLimitsReporterThread()
+
+	// ✅ 10.Prune data of old epochs according to retention policy
+	go threads.PruningThread()
+
 	//___________________ RUN SERVERS - WEBSOCKET AND HTTP __________________
 
 	// Set the atomic flag to true
@@ -61,6 +74,14 @@ func RunAnchorsChains() {
 
 func prepareAnchorsChains() error {
 
+	if globals.GENESIS.UsesBlsFinalizationProofs() {
+
+		if _, err := utils.GetBlsKeyPair(); err != nil {
+			return fmt.Errorf("BLS key: %w", err)
+		}
+
+	}
+
 	if info, err := os.Stat(globals.CHAINDATA_PATH); err != nil {
 
 		if os.IsNotExist(err) {
@@ -83,12 +104,21 @@ func prepareAnchorsChains() error {
 
 	}
 
-	databases.BLOCKS = utils.OpenDb("BLOCKS")
-	databases.EPOCH_DATA = utils.OpenDb("EPOCH_DATA")
-	databases.APPROVEMENT_THREAD_METADATA = utils.OpenDb("APPROVEMENT_THREAD_METADATA")
-	databases.FINALIZATION_VOTING_STATS = utils.OpenDb("FINALIZATION_VOTING_STATS")
+	if err := openDatabases(globals.CHAINDATA_PATH + "/DATABASES"); err != nil {
+		return fmt.Errorf("open databases: %w", err)
+	}
+
+	if err := databases.OpenSlashingProtection(globals.CHAINDATA_PATH + "/SLASHING_PROTECTION"); err != nil {
+		return fmt.Errorf("open slashing protection journal: %w", err)
+	}
+
+	// Chaindata of older nodes is upgraded before anything is read
+
+	if err := utils.MigrateChaindata(false); err != nil {
+		return fmt.Errorf("migrate chaindata: %w", err)
+	}
 
-	if data, err := databases.APPROVEMENT_THREAD_METADATA.Get([]byte("AT"), nil); err == nil {
+	if data, err := databases.APPROVEMENT_THREAD_METADATA.Get([]byte("AT")); err == nil {
 
 		var atHandler structures.ApprovementThreadMetadataHandler
 
@@ -110,7 +140,7 @@ func prepareAnchorsChains() error {
 			return fmt.Errorf("marshal APPROVEMENT_THREAD metadata: %w", err)
 		}
 
-		if err := databases.APPROVEMENT_THREAD_METADATA.Put([]byte("AT"), serializedApprovementThread, nil); err != nil {
+		if err := databases.APPROVEMENT_THREAD_METADATA.Put([]byte("AT"), serializedApprovementThread); err != nil {
 			return fmt.Errorf("save APPROVEMENT_THREAD metadata: %w", err)
 		}
 
@@ -120,16 +150,34 @@ func prepareAnchorsChains() error {
 	if err := loadGenerationThreadMetadata(); err != nil {
 		return err
 	}
+	if err := threads.RestoreMempool(); err != nil {
+		return fmt.Errorf("restore mempool: %w", err)
+	}
 	return nil
 }
 
-func loadGenesis() error {
+// openDatabases opens stores of chaindata and reports stores moved from the old layout
+func openDatabases(databasesDir string) error {
+
+	migrated, err := databases.OpenAll(databasesDir)
+
+	for _, store := range migrated {
+		utils.LogWithTime(fmt.Sprintf("Migrated %d records of %s to %s", store.Records, store.Name, databases.ROOT_STORE_NAME), utils.GREEN_COLOR)
+	}
+
+	return err
+
+}
 
-	approvementThreadBatch := new(leveldb.Batch)
+func loadGenesis() error {
 
-	epochTimestamp := globals.GENESIS.FirstEpochStartTimestamp
+	approvementThreadBatch := new(databases.Batch)
 
-	anchorsRegistryForEpochHandler := []string{}
+	switch globals.GENESIS.FinalizationProofsFormat {
+	case "", structures.FINALIZATION_PROOFS_FORMAT_ED25519, structures.FINALIZATION_PROOFS_FORMAT_BLS:
+	default:
+		return fmt.Errorf("unknown finalization proofs format %s", globals.GENESIS.FinalizationProofsFormat)
+	}
 
 	// __________________________________ Load info about anchors __________________________________
 
@@ -137,6 +185,14 @@ func loadGenesis() error {
 
 		anchorPubkey := anchorStorage.Pubkey
 
+		if globals.GENESIS.UsesBlsFinalizationProofs() {
+
+			if err := utils.VerifyAnchorBlsKey(&anchorStorage); err != nil {
+				return fmt.Errorf("anchor %s: %w", anchorPubkey, err)
+			}
+
+		}
+
 		serializedStorage, err := json.Marshal(anchorStorage)
 
 		if err != nil {
@@ -145,33 +201,23 @@ func loadGenesis() error {
 
 		approvementThreadBatch.Put([]byte(anchorPubkey+"_ANCHOR_STORAGE"), serializedStorage)
 
-		anchorsRegistryForEpochHandler = append(anchorsRegistryForEpochHandler, anchorPubkey)
-
 	}
 
 	handlers.APPROVEMENT_THREAD_METADATA.Handler.NetworkParameters = globals.GENESIS.NetworkParameters.CopyNetworkParameters()
 
-	// Commit changes
-
-	if err := databases.APPROVEMENT_THREAD_METADATA.Write(approvementThreadBatch, nil); err != nil {
-		return err
+	if err := utils.ValidateNetworkParameters(&handlers.APPROVEMENT_THREAD_METADATA.Handler.NetworkParameters); err != nil {
+		return fmt.Errorf("invalid network parameters: %w", err)
 	}
 
-	hashInput := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef" + globals.GENESIS.NetworkId
-
-	initEpochHash := utils.Blake3(hashInput)
+	// Commit changes
 
-	epochHandlerForApprovementThread := structures.EpochDataHandler{
-		Id:              0,
-		Hash:            initEpochHash,
-		AnchorsRegistry: anchorsRegistryForEpochHandler,
-		StartTimestamp:  epochTimestamp,
-		Quorum:          []string{}, // will be assigned
+	if err := databases.APPROVEMENT_THREAD_METADATA.Write(approvementThreadBatch); err != nil {
+		return err
 	}
 
-	// Assign quorum - pseudorandomly and in deterministic way
+	// Hash, registry, quorum and weights of the first epoch are derived from genesis only
 
-	epochHandlerForApprovementThread.Quorum = utils.GetCurrentEpochQuorum(&epochHandlerForApprovementThread, handlers.APPROVEMENT_THREAD_METADATA.Handler.NetworkParameters.QuorumSize, initEpochHash)
+	epochHandlerForApprovementThread := utils.BuildGenesisEpochHandler(&handlers.APPROVEMENT_THREAD_METADATA.Handler.NetworkParameters)
 
 	// Finally - assign a handler
 
@@ -189,7 +235,7 @@ func loadGenesis() error {
 		return fmt.Errorf("marshal genesis epoch handler: %w", err)
 	}
 
-	if err := databases.EPOCH_DATA.Put([]byte("EPOCH_HANDLER:"+strconv.Itoa(currentEpochDataHandler.Id)), jsonedCurrentEpochDataHandler, nil); err != nil {
+	if err := databases.EPOCH_DATA.Put([]byte("EPOCH_HANDLER:"+strconv.Itoa(currentEpochDataHandler.Id)), jsonedCurrentEpochDataHandler); err != nil {
 		return fmt.Errorf("store genesis epoch handler: %w", err)
 	}
 
@@ -198,8 +244,8 @@ func loadGenesis() error {
 }
 
 func ensureEpochWindow(handler *structures.ApprovementThreadMetadataHandler) {
-	if handler.NetworkParameters.MaxEpochsToSupport <= 0 {
-		handler.NetworkParameters.MaxEpochsToSupport = 1
+	if handler.NetworkParameters.MaxEpochsToSupport < 2 {
+		handler.NetworkParameters.MaxEpochsToSupport = 2
 	}
 	if len(handler.SupportedEpochs) == 0 {
 		handler.SupportedEpochs = []structures.EpochDataHandler{handler.EpochDataHandler}
@@ -210,9 +256,10 @@ func ensureEpochWindow(handler *structures.ApprovementThreadMetadataHandler) {
 		handler.SupportedEpochs = handler.SupportedEpochs[offset:]
 		for _, dropped := range toDrop {
 			keyValue := []byte("EPOCH_FINISH:" + strconv.Itoa(dropped.Id))
-			databases.FINALIZATION_VOTING_STATS.Put(keyValue, []byte("TRUE"), nil)
+			databases.FINALIZATION_VOTING_STATS.Put(keyValue, []byte("TRUE"))
 			epochFullID := dropped.Hash + "#" + strconv.Itoa(dropped.Id)
-			databases.BLOCKS.Delete([]byte("GT:"+epochFullID), nil)
+			databases.BLOCKS.Delete([]byte("GT:" + epochFullID))
+			utils.DeleteHealthSnapshotsOfEpoch(dropped.Id)
 		}
 	}
 	handler.SyncEpochPointers()
@@ -220,13 +267,11 @@ func ensureEpochWindow(handler *structures.ApprovementThreadMetadataHandler) {
 
 func loadGenerationThreadMetadata() error {
 	epochHandlers := handlers.APPROVEMENT_THREAD_METADATA.Handler.GetEpochHandlers()
-	legacyData, legacyErr := databases.BLOCKS.Get([]byte("GT"), nil)
-	legacyUsed := false
 
 	for _, epoch := range epochHandlers {
 		epochFullID := epoch.Hash + "#" + strconv.Itoa(epoch.Id)
 		key := []byte("GT:" + epochFullID)
-		if data, err := databases.BLOCKS.Get(key, nil); err == nil {
+		if data, err := databases.BLOCKS.Get(key); err == nil {
 			var gtHandler structures.GenerationThreadMetadataHandler
 			if err := json.Unmarshal(data, &gtHandler); err != nil {
 				return fmt.Errorf("unmarshal GENERATION_THREAD metadata: %w", err)
@@ -236,17 +281,6 @@ func loadGenerationThreadMetadata() error {
 			handlers.GENERATION_THREAD_METADATA.Unlock()
 			continue
 		}
-		if !legacyUsed && legacyErr == nil {
-			var gtHandler structures.GenerationThreadMetadataHandler
-			if err := json.Unmarshal(legacyData, &gtHandler); err == nil {
-				gtHandler.EpochFullId = epochFullID
-				handlers.GENERATION_THREAD_METADATA.Lock()
-				handlers.GENERATION_THREAD_METADATA.Handlers[epochFullID] = &gtHandler
-				handlers.GENERATION_THREAD_METADATA.Unlock()
-				legacyUsed = true
-				continue
-			}
-		}
 		handlers.GENERATION_THREAD_METADATA.Lock()
 		if _, ok := handlers.GENERATION_THREAD_METADATA.Handlers[epochFullID]; !ok {
 			handlers.GENERATION_THREAD_METADATA.Handlers[epochFullID] = &structures.GenerationThreadMetadataHandler{
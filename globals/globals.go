@@ -9,7 +9,11 @@ import (
 	"github.com/modulrcloud/modulr-anchors-core/structures"
 )
 
-var CHAINDATA_PATH = func() string {
+// CHAINDATA_PATH is set with ResolveChaindataPath on start of binary, so packages can be imported (e.g. by tests) without the environment
+var CHAINDATA_PATH string
+
+// ResolveChaindataPath reads CHAINDATA_PATH environment variable and creates the directory if it doesn't exist
+func ResolveChaindataPath() string {
 
 	dirPath := os.Getenv("CHAINDATA_PATH")
 
@@ -41,7 +45,7 @@ var CHAINDATA_PATH = func() string {
 
 	return dirPath
 
-}()
+}
 
 var CONFIGURATION structures.NodeLevelConfig
 
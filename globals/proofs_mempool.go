@@ -1,9 +1,14 @@
 package globals
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 
+	"github.com/modulrcloud/modulr-anchors-core/databases"
 	"github.com/modulrcloud/modulr-anchors-core/structures"
 )
 
@@ -11,46 +16,147 @@ type Mempool struct {
 	sync.Mutex
 	aggregatedAnchorRotationProofs     map[string]structures.AggregatedAnchorRotationProof     // proof for modulr-anchors-core logic to rotate anchors on demand
 	aggregatedLeaderFinalizationProofs map[string]structures.AggregatedLeaderFinalizationProof // proof for modulr-core logic to finalize last block by leader
+	anchorMembershipRequests           map[string]structures.AnchorMembershipRequest           // signed requests of anchors to join or leave the registry
+	networkParametersProofs            map[string]structures.AggregatedNetworkParametersProof  // quorum approved proposals to change network parameters
+	blockEquivocationEvidences         map[string]structures.BlockEquivocationEvidence         // proofs that block creator signed two different blocks with the same id
+	doubleVoteEvidences                map[string]structures.DoubleVoteEvidence                // proofs that quorum member voted for two different hashes of the same block
+	transactions                       map[string]structures.AnchorTransaction                 // signed transactions of anchors, bounded by TXS_MEMPOOL_SIZE
+	inFlight                           map[string]bool                                         // keys of items included to our blocks which are not finalized yet
 }
 
-// Mempool to store two types of proofs:
+// Mempool to store proofs, anchors membership requests and governance proofs.
+// Each item is also persisted in BLOCKS db under MEMPOOL_KEY_PREFIX and stays there until the block
+// which includes it is finalized, so items drained to not finalized blocks survive restarts:
 
 var MEMPOOL = Mempool{
 	aggregatedAnchorRotationProofs:     make(map[string]structures.AggregatedAnchorRotationProof),
 	aggregatedLeaderFinalizationProofs: make(map[string]structures.AggregatedLeaderFinalizationProof),
+	anchorMembershipRequests:           make(map[string]structures.AnchorMembershipRequest),
+	networkParametersProofs:            make(map[string]structures.AggregatedNetworkParametersProof),
+	blockEquivocationEvidences:         make(map[string]structures.BlockEquivocationEvidence),
+	doubleVoteEvidences:                make(map[string]structures.DoubleVoteEvidence),
+	transactions:                       make(map[string]structures.AnchorTransaction),
+	inFlight:                           make(map[string]bool),
 }
 
-func anchorMempoolKey(proof structures.AggregatedAnchorRotationProof) string {
-	return fmt.Sprintf("%d:%s:%d", proof.EpochIndex, proof.Anchor, proof.VotingStat.Index)
+var ErrMempoolIsFull = errors.New("mempool is full")
+
+// ErrMempoolStorage wraps errors of persistent storage of mempool. Item isn't added to memory if it wasn't persisted
+var ErrMempoolStorage = errors.New("mempool storage error")
+
+const MEMPOOL_KEY_PREFIX = "MEMPOOL:"
+
+func AggregatedAnchorRotationProofMempoolKey(proof structures.AggregatedAnchorRotationProof) string {
+	return fmt.Sprintf("%sAARP:%d:%s:%d", MEMPOOL_KEY_PREFIX, proof.EpochIndex, proof.Anchor, proof.VotingStat.Index)
+}
+
+func AggregatedLeaderFinalizationProofMempoolKey(proof structures.AggregatedLeaderFinalizationProof) string {
+	return fmt.Sprintf("%sALFP:%d:%s:%d", MEMPOOL_KEY_PREFIX, proof.EpochIndex, proof.Leader, proof.VotingStat.Index)
+}
+
+func AnchorMembershipRequestMempoolKey(request structures.AnchorMembershipRequest) string {
+	return fmt.Sprintf("%sMEMBERSHIP:%d:%s", MEMPOOL_KEY_PREFIX, request.EpochIndex, request.Anchor.Pubkey)
+}
+
+func AggregatedNetworkParametersProofMempoolKey(proof structures.AggregatedNetworkParametersProof) string {
+	return fmt.Sprintf("%sNETWORK_PARAMETERS:%d:%s", MEMPOOL_KEY_PREFIX, proof.Proposal.EpochIndex, proof.Proposal.Proposer)
 }
 
-func leaderMempoolKey(proof structures.AggregatedLeaderFinalizationProof) string {
-	return fmt.Sprintf("%d:%s:%d", proof.EpochIndex, proof.Leader, proof.VotingStat.Index)
+func BlockEquivocationEvidenceMempoolKey(evidence structures.BlockEquivocationEvidence) string {
+	return fmt.Sprintf("%sBLOCK_EQUIVOCATION:%s", MEMPOOL_KEY_PREFIX, evidence.BlockId)
 }
 
-func (mempool *Mempool) AddAggregatedAnchorRotationProof(proof structures.AggregatedAnchorRotationProof) {
+func DoubleVoteEvidenceMempoolKey(evidence structures.DoubleVoteEvidence) string {
+	return fmt.Sprintf("%sDOUBLE_VOTE:%s:%s", MEMPOOL_KEY_PREFIX, evidence.BlockId, evidence.Voter)
+}
+
+func AnchorTransactionMempoolKey(tx structures.AnchorTransaction) string {
+	return fmt.Sprintf("%sTX:%s:%d", MEMPOOL_KEY_PREFIX, tx.Creator, tx.Nonce)
+}
+
+func persistMempoolItem(key string, item any) error {
+
+	value, err := json.Marshal(item)
+
+	if err != nil {
+		return fmt.Errorf("%w: marshal %s: %v", ErrMempoolStorage, key, err)
+	}
+
+	if err := databases.BLOCKS.Put([]byte(key), value); err != nil {
+		return fmt.Errorf("%w: persist %s: %v", ErrMempoolStorage, key, err)
+	}
+
+	return nil
+
+}
+
+func forgetMempoolItems(keys []string) error {
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	batch := new(databases.Batch)
+
+	for _, key := range keys {
+		batch.Delete([]byte(key))
+	}
+
+	if err := databases.BLOCKS.Write(batch); err != nil {
+		return fmt.Errorf("%w: delete items: %v", ErrMempoolStorage, err)
+	}
+
+	return nil
+
+}
+
+func (mempool *Mempool) AddAggregatedAnchorRotationProof(proof structures.AggregatedAnchorRotationProof) error {
 
 	mempool.Lock()
+	defer mempool.Unlock()
 
 	if proof.Signatures == nil {
 		proof.Signatures = map[string]string{}
 	}
 
-	mempool.aggregatedAnchorRotationProofs[anchorMempoolKey(proof)] = proof
-	mempool.Unlock()
+	key := AggregatedAnchorRotationProofMempoolKey(proof)
+
+	if mempool.inFlight[key] {
+		return nil
+	}
+
+	if err := persistMempoolItem(key, proof); err != nil {
+		return err
+	}
+
+	mempool.aggregatedAnchorRotationProofs[key] = proof
+
+	return nil
 
 }
 
-func (mempool *Mempool) AddAggregatedLeaderFinalizationProof(proof structures.AggregatedLeaderFinalizationProof) {
+func (mempool *Mempool) AddAggregatedLeaderFinalizationProof(proof structures.AggregatedLeaderFinalizationProof) error {
 
 	mempool.Lock()
+	defer mempool.Unlock()
 
 	if proof.Signatures == nil {
 		proof.Signatures = map[string]string{}
 	}
 
-	mempool.aggregatedLeaderFinalizationProofs[leaderMempoolKey(proof)] = proof
-	mempool.Unlock()
+	key := AggregatedLeaderFinalizationProofMempoolKey(proof)
+
+	if mempool.inFlight[key] {
+		return nil
+	}
+
+	if err := persistMempoolItem(key, proof); err != nil {
+		return err
+	}
+
+	mempool.aggregatedLeaderFinalizationProofs[key] = proof
+
+	return nil
 
 }
 
@@ -75,23 +181,457 @@ func (mempool *Mempool) DrainAggregatedAnchorRotationProofs() []structures.Aggre
 
 }
 
-func (mempool *Mempool) DrainAggregatedLeaderFinalizationProofs() []structures.AggregatedLeaderFinalizationProof {
+// DrainAggregatedLeaderFinalizationProofs returns proofs of the provided epoch and earlier ones. Proofs of later epochs stay in mempool
+func (mempool *Mempool) DrainAggregatedLeaderFinalizationProofs(epochIndex int) []structures.AggregatedLeaderFinalizationProof {
 
 	mempool.Lock()
 	defer mempool.Unlock()
 
-	if len(mempool.aggregatedLeaderFinalizationProofs) == 0 {
+	var proofs []structures.AggregatedLeaderFinalizationProof
+
+	for key, proof := range mempool.aggregatedLeaderFinalizationProofs {
+		if proof.EpochIndex <= epochIndex {
+			proofs = append(proofs, proof)
+			delete(mempool.aggregatedLeaderFinalizationProofs, key)
+		}
+	}
+
+	return proofs
+
+}
+
+func (mempool *Mempool) AddAnchorMembershipRequest(request structures.AnchorMembershipRequest) error {
+
+	mempool.Lock()
+	defer mempool.Unlock()
+
+	key := AnchorMembershipRequestMempoolKey(request)
+
+	if mempool.inFlight[key] {
 		return nil
 	}
 
-	proofs := make([]structures.AggregatedLeaderFinalizationProof, 0, len(mempool.aggregatedLeaderFinalizationProofs))
+	if err := persistMempoolItem(key, request); err != nil {
+		return err
+	}
 
-	for _, proof := range mempool.aggregatedLeaderFinalizationProofs {
-		proofs = append(proofs, proof)
+	mempool.anchorMembershipRequests[key] = request
+
+	return nil
+
+}
+
+// DrainAnchorMembershipRequests returns requests for the provided epoch and forgets requests for older epochs
+func (mempool *Mempool) DrainAnchorMembershipRequests(epochIndex int) []structures.AnchorMembershipRequest {
+
+	mempool.Lock()
+	defer mempool.Unlock()
+
+	var outdated []string
+
+	var requests []structures.AnchorMembershipRequest
+
+	for key, request := range mempool.anchorMembershipRequests {
+		if request.EpochIndex == epochIndex {
+			requests = append(requests, request)
+			delete(mempool.anchorMembershipRequests, key)
+		}
+		if request.EpochIndex < epochIndex {
+			outdated = append(outdated, key)
+		}
 	}
 
-	mempool.aggregatedLeaderFinalizationProofs = make(map[string]structures.AggregatedLeaderFinalizationProof)
+	// Outdated items stay in memory if storage failed, so the next drain deletes them again
+
+	if forgetMempoolItems(outdated) == nil {
+		mempool.removePending(outdated)
+	}
+
+	return requests
+
+}
+
+func (mempool *Mempool) AddAggregatedNetworkParametersProof(proof structures.AggregatedNetworkParametersProof) error {
+
+	mempool.Lock()
+	defer mempool.Unlock()
+
+	key := AggregatedNetworkParametersProofMempoolKey(proof)
+
+	if mempool.inFlight[key] {
+		return nil
+	}
+
+	if err := persistMempoolItem(key, proof); err != nil {
+		return err
+	}
+
+	mempool.networkParametersProofs[key] = proof
+
+	return nil
+
+}
+
+// DrainAggregatedNetworkParametersProofs returns proofs for the provided epoch and forgets proofs for older epochs
+func (mempool *Mempool) DrainAggregatedNetworkParametersProofs(epochIndex int) []structures.AggregatedNetworkParametersProof {
+
+	mempool.Lock()
+	defer mempool.Unlock()
+
+	var outdated []string
+
+	var proofs []structures.AggregatedNetworkParametersProof
+
+	for key, proof := range mempool.networkParametersProofs {
+		if proof.Proposal.EpochIndex == epochIndex {
+			proofs = append(proofs, proof)
+			delete(mempool.networkParametersProofs, key)
+		}
+		if proof.Proposal.EpochIndex < epochIndex {
+			outdated = append(outdated, key)
+		}
+	}
+
+	// Outdated items stay in memory if storage failed, so the next drain deletes them again
+
+	if forgetMempoolItems(outdated) == nil {
+		mempool.removePending(outdated)
+	}
 
 	return proofs
 
 }
+
+func (mempool *Mempool) AddBlockEquivocationEvidence(evidence structures.BlockEquivocationEvidence) error {
+
+	mempool.Lock()
+	defer mempool.Unlock()
+
+	key := BlockEquivocationEvidenceMempoolKey(evidence)
+
+	if mempool.inFlight[key] {
+		return nil
+	}
+
+	if err := persistMempoolItem(key, evidence); err != nil {
+		return err
+	}
+
+	mempool.blockEquivocationEvidences[key] = evidence
+
+	return nil
+
+}
+
+// DrainBlockEquivocationEvidences returns evidences for the provided epoch and forgets evidences for older epochs
+func (mempool *Mempool) DrainBlockEquivocationEvidences(epochIndex int) []structures.BlockEquivocationEvidence {
+
+	mempool.Lock()
+	defer mempool.Unlock()
+
+	var outdated []string
+
+	var evidences []structures.BlockEquivocationEvidence
+
+	for key, evidence := range mempool.blockEquivocationEvidences {
+		if evidence.EpochIndex == epochIndex {
+			evidences = append(evidences, evidence)
+			delete(mempool.blockEquivocationEvidences, key)
+		}
+		if evidence.EpochIndex < epochIndex {
+			outdated = append(outdated, key)
+		}
+	}
+
+	// Outdated items stay in memory if storage failed, so the next drain deletes them again
+
+	if forgetMempoolItems(outdated) == nil {
+		mempool.removePending(outdated)
+	}
+
+	return evidences
+
+}
+
+func (mempool *Mempool) AddDoubleVoteEvidence(evidence structures.DoubleVoteEvidence) error {
+
+	mempool.Lock()
+	defer mempool.Unlock()
+
+	key := DoubleVoteEvidenceMempoolKey(evidence)
+
+	if mempool.inFlight[key] {
+		return nil
+	}
+
+	if err := persistMempoolItem(key, evidence); err != nil {
+		return err
+	}
+
+	mempool.doubleVoteEvidences[key] = evidence
+
+	return nil
+
+}
+
+// DrainDoubleVoteEvidences returns evidences for the provided epoch and forgets evidences for older epochs
+func (mempool *Mempool) DrainDoubleVoteEvidences(epochIndex int) []structures.DoubleVoteEvidence {
+
+	mempool.Lock()
+	defer mempool.Unlock()
+
+	var outdated []string
+
+	var evidences []structures.DoubleVoteEvidence
+
+	for key, evidence := range mempool.doubleVoteEvidences {
+		if evidence.EpochIndex == epochIndex {
+			evidences = append(evidences, evidence)
+			delete(mempool.doubleVoteEvidences, key)
+		}
+		if evidence.EpochIndex < epochIndex {
+			outdated = append(outdated, key)
+		}
+	}
+
+	// Outdated items stay in memory if storage failed, so the next drain deletes them again
+
+	if forgetMempoolItems(outdated) == nil {
+		mempool.removePending(outdated)
+	}
+
+	return evidences
+
+}
+
+// AddTransaction puts transaction to mempool unless it already has maxSize transactions
+func (mempool *Mempool) AddTransaction(tx structures.AnchorTransaction, maxSize int) error {
+
+	mempool.Lock()
+	defer mempool.Unlock()
+
+	key := AnchorTransactionMempoolKey(tx)
+
+	if _, exists := mempool.transactions[key]; exists || mempool.inFlight[key] {
+		return errors.New("transaction is already in mempool")
+	}
+
+	if len(mempool.transactions) >= maxSize {
+		return ErrMempoolIsFull
+	}
+
+	if err := persistMempoolItem(key, tx); err != nil {
+		return err
+	}
+
+	mempool.transactions[key] = tx
+
+	return nil
+
+}
+
+// ReturnTransactions puts back transactions which didn't fit into block or were included to block which will never be finalized.
+// They were in mempool before, so size limit is not checked
+func (mempool *Mempool) ReturnTransactions(transactions []structures.AnchorTransaction) error {
+
+	mempool.Lock()
+	defer mempool.Unlock()
+
+	for _, tx := range transactions {
+
+		key := AnchorTransactionMempoolKey(tx)
+
+		if err := persistMempoolItem(key, tx); err != nil {
+			return err
+		}
+
+		mempool.transactions[key] = tx
+
+	}
+
+	return nil
+
+}
+
+// DrainTransactions returns up to limit transactions ordered by creator and nonce
+func (mempool *Mempool) DrainTransactions(limit int) []structures.AnchorTransaction {
+
+	mempool.Lock()
+	defer mempool.Unlock()
+
+	if len(mempool.transactions) == 0 || limit <= 0 {
+		return nil
+	}
+
+	transactions := make([]structures.AnchorTransaction, 0, len(mempool.transactions))
+
+	for _, tx := range mempool.transactions {
+		transactions = append(transactions, tx)
+	}
+
+	sort.Slice(transactions, func(i, j int) bool {
+		if transactions[i].Creator != transactions[j].Creator {
+			return transactions[i].Creator < transactions[j].Creator
+		}
+		return transactions[i].Nonce < transactions[j].Nonce
+	})
+
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+	}
+
+	for _, tx := range transactions {
+		delete(mempool.transactions, AnchorTransactionMempoolKey(tx))
+	}
+
+	return transactions
+
+}
+
+// Forget removes items from mempool and its persistent storage. Used for items of finalized blocks
+// and for items which became invalid. Items stay in memory if storage failed
+func (mempool *Mempool) Forget(keys []string) error {
+
+	mempool.Lock()
+	defer mempool.Unlock()
+
+	if err := forgetMempoolItems(keys); err != nil {
+		return err
+	}
+
+	mempool.forgetInMemory(keys)
+
+	return nil
+
+}
+
+// ForgetInBatch adds deletion of persisted items to batch. Call ForgetCommitted once the batch is written
+func (mempool *Mempool) ForgetInBatch(keys []string, batch *databases.AtomicBatch) {
+
+	for _, key := range keys {
+		batch.Delete(databases.BLOCKS, []byte(key))
+	}
+
+}
+
+// ForgetCommitted removes items from memory after their deletion was committed with ForgetInBatch
+func (mempool *Mempool) ForgetCommitted(keys []string) {
+
+	mempool.Lock()
+	defer mempool.Unlock()
+
+	mempool.forgetInMemory(keys)
+
+}
+
+func (mempool *Mempool) forgetInMemory(keys []string) {
+
+	mempool.removePending(keys)
+
+	for _, key := range keys {
+		delete(mempool.inFlight, key)
+	}
+
+}
+
+// MarkInFlight removes items from pending ones but keeps them in persistent storage,
+// because they are included to our block which is not finalized yet. Until release such items can't be added again
+func (mempool *Mempool) MarkInFlight(keys []string) {
+
+	mempool.Lock()
+	defer mempool.Unlock()
+
+	mempool.removePending(keys)
+
+	for _, key := range keys {
+		mempool.inFlight[key] = true
+	}
+
+}
+
+// ReleaseInFlight allows to add items again, used when the block with them will never be finalized
+func (mempool *Mempool) ReleaseInFlight(keys []string) {
+
+	mempool.Lock()
+	defer mempool.Unlock()
+
+	for _, key := range keys {
+		delete(mempool.inFlight, key)
+	}
+
+}
+
+func (mempool *Mempool) removePending(keys []string) {
+
+	for _, key := range keys {
+		delete(mempool.aggregatedAnchorRotationProofs, key)
+		delete(mempool.aggregatedLeaderFinalizationProofs, key)
+		delete(mempool.anchorMembershipRequests, key)
+		delete(mempool.networkParametersProofs, key)
+		delete(mempool.blockEquivocationEvidences, key)
+		delete(mempool.doubleVoteEvidences, key)
+		delete(mempool.transactions, key)
+	}
+
+}
+
+// LoadPersisted restores all the items from persistent storage as pending. Items of not finalized blocks
+// should be marked as in-flight afterwards
+func (mempool *Mempool) LoadPersisted() error {
+
+	mempool.Lock()
+	defer mempool.Unlock()
+
+	iterator := databases.BLOCKS.NewIterator([]byte(MEMPOOL_KEY_PREFIX))
+	defer iterator.Release()
+
+	for iterator.Next() {
+
+		key := string(iterator.Key())
+
+		category, _, _ := strings.Cut(strings.TrimPrefix(key, MEMPOOL_KEY_PREFIX), ":")
+
+		var err error
+
+		switch category {
+		case "AARP":
+			err = loadMempoolItem(iterator.Value(), key, mempool.aggregatedAnchorRotationProofs)
+		case "ALFP":
+			err = loadMempoolItem(iterator.Value(), key, mempool.aggregatedLeaderFinalizationProofs)
+		case "MEMBERSHIP":
+			err = loadMempoolItem(iterator.Value(), key, mempool.anchorMembershipRequests)
+		case "NETWORK_PARAMETERS":
+			err = loadMempoolItem(iterator.Value(), key, mempool.networkParametersProofs)
+		case "BLOCK_EQUIVOCATION":
+			err = loadMempoolItem(iterator.Value(), key, mempool.blockEquivocationEvidences)
+		case "DOUBLE_VOTE":
+			err = loadMempoolItem(iterator.Value(), key, mempool.doubleVoteEvidences)
+		case "TX":
+			err = loadMempoolItem(iterator.Value(), key, mempool.transactions)
+		default:
+			err = errors.New("unknown category")
+		}
+
+		if err != nil {
+			return fmt.Errorf("load mempool item %s: %w", key, err)
+		}
+
+	}
+
+	return iterator.Error()
+
+}
+
+func loadMempoolItem[T any](raw []byte, key string, items map[string]T) error {
+
+	var item T
+
+	if err := json.Unmarshal(raw, &item); err != nil {
+		return err
+	}
+
+	items[key] = item
+
+	return nil
+
+}
@@ -4,6 +4,7 @@ go 1.24.0
 
 require (
 	github.com/btcsuite/btcutil v1.0.2
+	github.com/cloudflare/circl v1.6.1
 	github.com/fasthttp/router v1.5.4
 	github.com/gorilla/websocket v1.5.3
 	github.com/lxzan/gws v1.8.8
@@ -26,4 +27,5 @@ require (
 	github.com/valyala/bytebufferpool v1.0.0 // indirect
 	golang.org/x/crypto v0.37.0 // indirect
 	golang.org/x/net v0.39.0 // indirect
+	golang.org/x/sys v0.32.0 // indirect
 )
@@ -0,0 +1,109 @@
+package http_pack
+
+import (
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/modulrcloud/modulr-anchors-core/globals"
+	"github.com/modulrcloud/modulr-anchors-core/utils"
+
+	"github.com/valyala/fasthttp"
+)
+
+// limitedListener drops new connections of IP which already has HTTP_MAX_CONNECTIONS_PER_IP open ones
+type limitedListener struct {
+	net.Listener
+	limiter *utils.ConnectionsLimiter
+}
+
+type limitedConn struct {
+	net.Conn
+	ip      string
+	limiter *utils.ConnectionsLimiter
+	release sync.Once
+}
+
+func (listener *limitedListener) Accept() (net.Conn, error) {
+
+	for {
+
+		conn, err := listener.Listener.Accept()
+
+		if err != nil {
+			return nil, err
+		}
+
+		ip := utils.IpFromAddress(conn.RemoteAddr().String())
+
+		if !listener.limiter.Acquire(ip) {
+			utils.RATE_LIMITS_METRICS.HttpConnectionsRejected.Add(1)
+			conn.Close()
+			continue
+		}
+
+		return &limitedConn{Conn: conn, ip: ip, limiter: listener.limiter}, nil
+
+	}
+
+}
+
+func (conn *limitedConn) Close() error {
+
+	conn.release.Do(func() { conn.limiter.Release(conn.ip) })
+
+	return conn.Conn.Close()
+
+}
+
+// rateLimited checks token buckets of client IP and of IP for the route before handling request
+func rateLimited(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+
+	limits := globals.CONFIGURATION.RateLimits
+
+	perIp := utils.NewRateLimiter(limits.HttpPerIp)
+
+	perRoute := utils.NewKeyedRateLimiters(limits.HttpPerRoute)
+
+	return func(ctx *fasthttp.RequestCtx) {
+
+		ip := utils.IpFromAddress(ctx.RemoteAddr().String())
+
+		if !perIp.Allow(ip) || !perRoute.Allow(string(ctx.Path()), ip) {
+			utils.RATE_LIMITS_METRICS.HttpRateLimited.Add(1)
+			ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")
+			ctx.SetContentType("application/json")
+			ctx.SetStatusCode(fasthttp.StatusTooManyRequests)
+			ctx.Write([]byte(`{"err":"rate limit exceeded"}`))
+			return
+		}
+
+		next(ctx)
+
+	}
+
+}
+
+func handleServerError(ctx *fasthttp.RequestCtx, err error) {
+
+	if errors.Is(err, fasthttp.ErrBodyTooLarge) {
+		utils.RATE_LIMITS_METRICS.HttpBodyTooLarge.Add(1)
+		ctx.SetContentType("application/json")
+		ctx.SetStatusCode(fasthttp.StatusRequestEntityTooLarge)
+		ctx.Write([]byte(`{"err":"body too large"}`))
+		return
+	}
+
+	if _, ok := err.(*fasthttp.ErrSmallBuffer); ok {
+		ctx.Error("Too big request header", fasthttp.StatusRequestHeaderFieldsTooLarge)
+		return
+	}
+
+	if netErr, ok := err.(*net.OpError); ok && netErr.Timeout() {
+		ctx.Error("Request timeout", fasthttp.StatusRequestTimeout)
+		return
+	}
+
+	ctx.Error("Error when parsing request", fasthttp.StatusBadRequest)
+
+}
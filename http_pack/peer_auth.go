@@ -0,0 +1,136 @@
+package http_pack
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/modulrcloud/modulr-anchors-core/cryptography"
+	"github.com/modulrcloud/modulr-anchors-core/handlers"
+	"github.com/modulrcloud/modulr-anchors-core/utils"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/valyala/fasthttp"
+)
+
+// Max difference between timestamp of signed request and our time. Nonces are remembered for the same period
+const PEER_REQUEST_MAX_AGE_MS = 30000
+
+type peerNonces struct {
+	sync.Mutex
+	seen      map[string]int64 // pubkey:nonce => timestamp of request
+	lastPrune int64
+}
+
+var usedPeerNonces = peerNonces{seen: make(map[string]int64)}
+
+// remember returns false if nonce was already used by this anchor
+func (nonces *peerNonces) remember(pubkey, nonce string, timestamp, now int64) bool {
+
+	nonces.Lock()
+	defer nonces.Unlock()
+
+	if now-nonces.lastPrune > PEER_REQUEST_MAX_AGE_MS {
+		for key, seenAt := range nonces.seen {
+			if now-seenAt > PEER_REQUEST_MAX_AGE_MS {
+				delete(nonces.seen, key)
+			}
+		}
+		nonces.lastPrune = now
+	}
+
+	key := pubkey + ":" + nonce
+
+	if _, used := nonces.seen[key]; used {
+		return false
+	}
+
+	nonces.seen[key] = timestamp
+
+	return true
+
+}
+
+// AuthenticatedPeer wraps route which should be called only by anchors. Caller signs method, path, timestamp, nonce
+// and body hash (see utils.PostJSON). Request is accepted if caller is a registered anchor or quorum member of any supported epoch
+func AuthenticatedPeer(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+
+	return func(ctx *fasthttp.RequestCtx) {
+
+		if err := verifyPeerRequest(ctx); err != nil {
+			ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")
+			ctx.SetContentType("application/json")
+			ctx.SetStatusCode(fasthttp.StatusUnauthorized)
+			ctx.Write([]byte(fmt.Sprintf(`{"err":"%s"}`, err.Error())))
+			return
+		}
+
+		next(ctx)
+
+	}
+
+}
+
+func verifyPeerRequest(ctx *fasthttp.RequestCtx) error {
+
+	pubkey := string(ctx.Request.Header.Peek(utils.PEER_PUBKEY_HEADER))
+	timestampHeader := string(ctx.Request.Header.Peek(utils.PEER_TIMESTAMP_HEADER))
+	nonce := string(ctx.Request.Header.Peek(utils.PEER_NONCE_HEADER))
+	signature := string(ctx.Request.Header.Peek(utils.PEER_SIGNATURE_HEADER))
+
+	if pubkey == "" || timestampHeader == "" || nonce == "" || signature == "" {
+		return fmt.Errorf("missing authentication headers")
+	}
+
+	if len(base58.Decode(pubkey)) != 32 || len(nonce) > 64 {
+		return fmt.Errorf("malformed authentication headers")
+	}
+
+	timestamp, err := strconv.ParseInt(timestampHeader, 10, 64)
+
+	if err != nil {
+		return fmt.Errorf("invalid timestamp")
+	}
+
+	now := utils.GetUTCTimestampInMilliSeconds()
+
+	if timestamp > now+PEER_REQUEST_MAX_AGE_MS || now-timestamp > PEER_REQUEST_MAX_AGE_MS {
+		return fmt.Errorf("request timestamp is out of allowed window")
+	}
+
+	if !isKnownAnchor(pubkey) {
+		return fmt.Errorf("caller is not a registered anchor")
+	}
+
+	dataThatShouldBeSigned := utils.GetPeerRequestSigningData(string(ctx.Method()), string(ctx.Path()), timestampHeader, nonce, ctx.PostBody())
+
+	if !cryptography.VerifySignature(dataThatShouldBeSigned, pubkey, signature) {
+		return fmt.Errorf("invalid request signature")
+	}
+
+	// Nonce is remembered only for requests with valid signature, so nobody can burn nonces of other anchors
+
+	if !usedPeerNonces.remember(pubkey, nonce, timestamp, now) {
+		return fmt.Errorf("nonce was already used")
+	}
+
+	return nil
+
+}
+
+func isKnownAnchor(pubkey string) bool {
+
+	handlers.APPROVEMENT_THREAD_METADATA.RWMutex.RLock()
+
+	defer handlers.APPROVEMENT_THREAD_METADATA.RWMutex.RUnlock()
+
+	for _, epochHandler := range handlers.APPROVEMENT_THREAD_METADATA.Handler.GetEpochHandlers() {
+		if slices.Contains(epochHandler.AnchorsRegistry, pubkey) || slices.Contains(epochHandler.Quorum, pubkey) {
+			return true
+		}
+	}
+
+	return false
+
+}
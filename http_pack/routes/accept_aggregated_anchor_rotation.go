@@ -2,6 +2,7 @@ package routes
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"slices"
 	"strconv"
@@ -44,7 +45,11 @@ func AcceptAggregatedAnchorRotationProofs(ctx *fasthttp.RequestCtx) {
 
 	for _, proof := range req.AggregatedRotationProofs {
 		if err := storeAggregatedRotationProofFromRequest(proof); err != nil {
-			ctx.SetStatusCode(fasthttp.StatusBadRequest)
+			if errors.Is(err, globals.ErrMempoolStorage) {
+				ctx.SetStatusCode(fasthttp.StatusInternalServerError)
+			} else {
+				ctx.SetStatusCode(fasthttp.StatusBadRequest)
+			}
 			ctx.Write([]byte(fmt.Sprintf(`{"err":"%s"}`, err.Error())))
 			return
 		}
@@ -71,10 +76,12 @@ func storeAggregatedRotationProofFromRequest(proof structures.AggregatedAnchorRo
 		return fmt.Errorf("creator %s is not part of epoch %d", proof.Anchor, proof.EpochIndex)
 	}
 
-	majority := utils.GetQuorumMajority(epochHandler)
+	quorumWeights := utils.GetQuorumWeights(epochHandler)
 
-	if len(proof.Signatures) < majority {
-		return fmt.Errorf("insufficient signatures: %d < %d", len(proof.Signatures), majority)
+	majority := utils.GetQuorumMajorityByWeights(quorumWeights)
+
+	if signaturesWeight := utils.GetSignaturesWeight(quorumWeights, proof.Signatures); signaturesWeight < majority {
+		return fmt.Errorf("insufficient signatures weight: %d < %d", signaturesWeight, majority)
 	}
 
 	creatorMutex := globals.BLOCK_CREATORS_MUTEX_REGISTRY.GetMutex(proof.EpochIndex, proof.Anchor)
@@ -93,7 +100,9 @@ func storeAggregatedRotationProofFromRequest(proof structures.AggregatedAnchorRo
 
 	if existing, err := utils.LoadAggregatedAnchorRotationProof(proof.EpochIndex, proof.Anchor); err == nil {
 		if existing.VotingStat.Index >= proof.VotingStat.Index && existing.VotingStat.Hash == proof.VotingStat.Hash {
-			globals.MEMPOOL.AddAggregatedAnchorRotationProof(existing)
+			if !utils.IsAggregatedAnchorRotationProofIncluded(&existing) {
+				return globals.MEMPOOL.AddAggregatedAnchorRotationProof(existing)
+			}
 			return nil
 		}
 	}
@@ -102,7 +111,17 @@ func storeAggregatedRotationProofFromRequest(proof structures.AggregatedAnchorRo
 		return fmt.Errorf("store rotation proof: %w", err)
 	}
 
-	globals.MEMPOOL.AddAggregatedAnchorRotationProof(proof)
+	// Rotation is final - creator can't be reinstated anymore
+
+	if !utils.IsFinalizationProofsDisabled(proof.EpochIndex, proof.Anchor) {
+		if err := utils.DisableFinalizationProofsForCreator(proof.EpochIndex, proof.Anchor, proof.VotingStat.Index); err != nil {
+			return fmt.Errorf("disable proofs for creator: %w", err)
+		}
+	}
+
+	if !utils.IsAggregatedAnchorRotationProofIncluded(&proof) {
+		return globals.MEMPOOL.AddAggregatedAnchorRotationProof(proof)
+	}
 
 	return nil
 
@@ -132,15 +151,10 @@ func verifyAggregatedAnchorRotationProof(proof *structures.AggregatedAnchorRotat
 	}
 
 	epochFullID := epochHandler.Hash + "#" + strconv.Itoa(epochHandler.Id)
-	dataToVerify := strings.Join([]string{
-		proof.VotingStat.Afp.PrevBlockHash,
-		proof.VotingStat.Afp.BlockId,
-		proof.VotingStat.Afp.BlockHash,
-		epochFullID,
-	}, ":")
-
-	quorum := epochHandler.Quorum
-	verified := 0
+	dataToVerify := utils.GetAnchorRotationProofSigningData(epochHandler.SigningPayloadVersion, &proof.VotingStat, epochFullID)
+
+	quorumWeights := utils.GetQuorumWeights(epochHandler)
+	verifiedWeight := uint64(0)
 	seen := make(map[string]struct{})
 	for voter, signature := range proof.Signatures {
 		if signature == "" {
@@ -149,19 +163,20 @@ func verifyAggregatedAnchorRotationProof(proof *structures.AggregatedAnchorRotat
 		if _, dup := seen[voter]; dup {
 			continue
 		}
-		if !slices.Contains(quorum, voter) {
+		weight, inQuorum := quorumWeights[voter]
+		if !inQuorum {
 			continue
 		}
 		if !cryptography.VerifySignature(dataToVerify, voter, signature) {
 			continue
 		}
 		seen[voter] = struct{}{}
-		verified++
+		verifiedWeight += weight
 	}
 
-	majority := utils.GetQuorumMajority(epochHandler)
-	if verified < majority {
-		return fmt.Errorf("verified signatures %d < %d", verified, majority)
+	majority := utils.GetQuorumMajorityByWeights(quorumWeights)
+	if verifiedWeight < majority {
+		return fmt.Errorf("verified signatures weight %d < %d", verifiedWeight, majority)
 	}
 	return nil
 }
@@ -0,0 +1,69 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/modulrcloud/modulr-anchors-core/globals"
+	"github.com/modulrcloud/modulr-anchors-core/structures"
+	"github.com/modulrcloud/modulr-anchors-core/utils"
+
+	"github.com/valyala/fasthttp"
+)
+
+func AcceptAnchorMembershipRequests(ctx *fasthttp.RequestCtx) {
+
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")
+	ctx.SetContentType("application/json")
+
+	if !ctx.IsPost() {
+		ctx.SetStatusCode(fasthttp.StatusMethodNotAllowed)
+		ctx.Write([]byte(`{"err":"method not allowed"}`))
+		return
+	}
+
+	var req structures.AcceptAnchorMembershipRequestsRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		ctx.SetStatusCode(fasthttp.StatusBadRequest)
+		ctx.Write([]byte(`{"err":"invalid payload"}`))
+		return
+	}
+
+	if len(req.MembershipRequests) == 0 {
+		ctx.SetStatusCode(fasthttp.StatusBadRequest)
+		ctx.Write([]byte(`{"err":"missing membership requests"}`))
+		return
+	}
+
+	accepted := 0
+	for _, request := range req.MembershipRequests {
+		if err := validateAnchorMembershipRequest(&request); err != nil {
+			ctx.SetStatusCode(fasthttp.StatusBadRequest)
+			ctx.Write([]byte(fmt.Sprintf(`{"err":"%s"}`, err.Error())))
+			return
+		}
+		if err := globals.MEMPOOL.AddAnchorMembershipRequest(request); err != nil {
+			ctx.SetStatusCode(fasthttp.StatusInternalServerError)
+			ctx.Write([]byte(fmt.Sprintf(`{"err":"%s"}`, err.Error())))
+			return
+		}
+		accepted++
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	payload, _ := json.Marshal(structures.AcceptAnchorRotationProofResponse{Accepted: accepted})
+	ctx.Write(payload)
+}
+
+func validateAnchorMembershipRequest(request *structures.AnchorMembershipRequest) error {
+
+	// Requests are included only to blocks of the epoch they target and applied when this epoch finishes
+
+	epochHandler := utils.GetEpochHandlerByID(request.EpochIndex)
+
+	if epochHandler == nil {
+		return fmt.Errorf("epoch %d is not tracked", request.EpochIndex)
+	}
+
+	return utils.VerifyAnchorMembershipRequest(request, epochHandler)
+}
@@ -20,7 +20,7 @@ func GetBlockById(ctx *fasthttp.RequestCtx) {
 		return
 	}
 
-	block, err := databases.BLOCKS.Get([]byte(blockId), nil)
+	block, err := databases.BLOCKS.Get([]byte(blockId))
 
 	if err == nil && block != nil {
 		ctx.SetStatusCode(fasthttp.StatusOK)
@@ -48,7 +48,7 @@ func GetAggregatedFinalizationProof(ctx *fasthttp.RequestCtx) {
 		return
 	}
 
-	afp, err := databases.EPOCH_DATA.Get([]byte("AFP:"+blockId), nil)
+	afp, err := databases.EPOCH_DATA.Get([]byte("AFP:" + blockId))
 
 	if err == nil && afp != nil {
 		ctx.SetStatusCode(fasthttp.StatusOK)
@@ -0,0 +1,118 @@
+package routes
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/modulrcloud/modulr-anchors-core/databases"
+	"github.com/modulrcloud/modulr-anchors-core/structures"
+	"github.com/modulrcloud/modulr-anchors-core/utils"
+
+	"github.com/valyala/fasthttp"
+)
+
+func newRequestCtx(userValues map[string]string) *fasthttp.RequestCtx {
+
+	ctx := new(fasthttp.RequestCtx)
+
+	for key, value := range userValues {
+		ctx.SetUserValue(key, value)
+	}
+
+	return ctx
+
+}
+
+func TestGetBlockById(t *testing.T) {
+
+	databases.UseMemoryStores()
+
+	block := []byte(`{"creator":"anchor","index":0}`)
+
+	if err := databases.BLOCKS.Put([]byte("0:anchor:0"), block); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := newRequestCtx(map[string]string{"id": "0:anchor:0"})
+
+	GetBlockById(ctx)
+
+	if ctx.Response.StatusCode() != fasthttp.StatusOK || string(ctx.Response.Body()) != string(block) {
+		t.Fatalf("stored block: %d %s", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+
+	ctx = newRequestCtx(map[string]string{"id": "0:anchor:1"})
+
+	GetBlockById(ctx)
+
+	if ctx.Response.StatusCode() != fasthttp.StatusNotFound {
+		t.Fatalf("missing block: expected 404, got %d", ctx.Response.StatusCode())
+	}
+
+}
+
+func TestGetAggregatedEpochFinishProof(t *testing.T) {
+
+	databases.UseMemoryStores()
+
+	proof := structures.AggregatedEpochFinishProof{
+		Summary: structures.EpochFinishSummary{
+			EpochIndex:  3,
+			VotingStats: map[string]structures.VotingStat{"anchor": {Index: 5, Hash: "hash"}},
+		},
+		Signatures: map[string]string{"voter": "signature"},
+	}
+
+	if err := utils.StoreAggregatedEpochFinishProof(proof); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := newRequestCtx(map[string]string{"epochIndex": "3"})
+
+	GetAggregatedEpochFinishProof(ctx)
+
+	var returned structures.AggregatedEpochFinishProof
+
+	if ctx.Response.StatusCode() != fasthttp.StatusOK || json.Unmarshal(ctx.Response.Body(), &returned) != nil {
+		t.Fatalf("stored proof: %d %s", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+
+	if returned.Summary.VotingStats["anchor"].Index != 5 || returned.Signatures["voter"] != "signature" {
+		t.Fatalf("returned proof differs from the stored one: %s", ctx.Response.Body())
+	}
+
+	for epochIndex, status := range map[string]int{"4": fasthttp.StatusNotFound, "-1": fasthttp.StatusBadRequest, "x": fasthttp.StatusBadRequest} {
+
+		ctx := newRequestCtx(map[string]string{"epochIndex": epochIndex})
+
+		GetAggregatedEpochFinishProof(ctx)
+
+		if ctx.Response.StatusCode() != status {
+			t.Fatalf("epoch %s: expected %d, got %d", epochIndex, status, ctx.Response.StatusCode())
+		}
+
+	}
+
+}
+
+func TestGetRateLimitsMetrics(t *testing.T) {
+
+	before := utils.RATE_LIMITS_METRICS.Counters()
+
+	utils.RATE_LIMITS_METRICS.WsRateLimited.Add(2)
+
+	ctx := newRequestCtx(nil)
+
+	GetRateLimitsMetrics(ctx)
+
+	var counters utils.RateLimitsCounters
+
+	if ctx.Response.StatusCode() != fasthttp.StatusOK || json.Unmarshal(ctx.Response.Body(), &counters) != nil {
+		t.Fatalf("metrics: %d %s", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+
+	if counters.WsRateLimited != before.WsRateLimited+2 || counters.HttpRateLimited != before.HttpRateLimited {
+		t.Fatalf("returned counters differ from the metrics: %s", ctx.Response.Body())
+	}
+
+}
@@ -0,0 +1,46 @@
+package routes
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/modulrcloud/modulr-anchors-core/utils"
+
+	"github.com/valyala/fasthttp"
+)
+
+func GetBlockEquivocationEvidences(ctx *fasthttp.RequestCtx) {
+
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")
+	ctx.SetContentType("application/json")
+
+	epochIndexRaw, _ := ctx.UserValue("epochIndex").(string)
+
+	epochIndex, err := strconv.Atoi(epochIndexRaw)
+
+	if err != nil || epochIndex < 0 {
+		ctx.SetStatusCode(fasthttp.StatusBadRequest)
+		ctx.Write([]byte(`{"err": "Invalid value"}`))
+		return
+	}
+
+	evidences, err := utils.LoadBlockEquivocationEvidences(epochIndex)
+
+	if err != nil {
+		ctx.SetStatusCode(fasthttp.StatusInternalServerError)
+		ctx.Write([]byte(`{"err": "Failed to load evidences"}`))
+		return
+	}
+
+	payload, err := json.Marshal(evidences)
+
+	if err != nil {
+		ctx.SetStatusCode(fasthttp.StatusInternalServerError)
+		ctx.Write([]byte(`{"err": "Failed to marshal evidences"}`))
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.Write(payload)
+
+}
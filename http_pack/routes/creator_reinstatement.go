@@ -0,0 +1,96 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/modulrcloud/modulr-anchors-core/globals"
+	"github.com/modulrcloud/modulr-anchors-core/structures"
+	"github.com/modulrcloud/modulr-anchors-core/utils"
+
+	"github.com/valyala/fasthttp"
+)
+
+// RequestCreatorReinstatement is used by creator disabled by health checker to collect quorum signatures for reinstatement
+func RequestCreatorReinstatement(ctx *fasthttp.RequestCtx) {
+
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")
+	ctx.SetContentType("application/json")
+
+	if !ctx.IsPost() {
+		ctx.SetStatusCode(fasthttp.StatusMethodNotAllowed)
+		ctx.Write([]byte(`{"err":"method not allowed"}`))
+		return
+	}
+
+	var req structures.CreatorReinstatementRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		ctx.SetStatusCode(fasthttp.StatusBadRequest)
+		ctx.Write([]byte(`{"err":"invalid payload"}`))
+		return
+	}
+
+	epochHandler := utils.GetEpochHandlerByID(req.EpochIndex)
+	if epochHandler == nil {
+		ctx.SetStatusCode(fasthttp.StatusNotFound)
+		ctx.Write([]byte(`{"err":"epoch not found"}`))
+		return
+	}
+
+	if !slices.Contains(epochHandler.Quorum, globals.CONFIGURATION.PublicKey) {
+		ctx.SetStatusCode(fasthttp.StatusForbidden)
+		ctx.Write([]byte(`{"err":"not a quorum member"}`))
+		return
+	}
+
+	response := utils.ProcessCreatorReinstatementRequest(&req, epochHandler)
+
+	switch response.Status {
+	case "OK":
+		ctx.SetStatusCode(fasthttp.StatusOK)
+	case "REJECTED":
+		ctx.SetStatusCode(fasthttp.StatusConflict)
+	default:
+		ctx.SetStatusCode(fasthttp.StatusBadRequest)
+	}
+
+	payload, _ := json.Marshal(response)
+	ctx.Write(payload)
+}
+
+// AcceptAggregatedCreatorReinstatementProof clears the health flag of creator reinstated by quorum majority
+func AcceptAggregatedCreatorReinstatementProof(ctx *fasthttp.RequestCtx) {
+
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")
+	ctx.SetContentType("application/json")
+
+	if !ctx.IsPost() {
+		ctx.SetStatusCode(fasthttp.StatusMethodNotAllowed)
+		ctx.Write([]byte(`{"err":"method not allowed"}`))
+		return
+	}
+
+	var proof structures.AggregatedCreatorReinstatementProof
+	if err := json.Unmarshal(ctx.PostBody(), &proof); err != nil {
+		ctx.SetStatusCode(fasthttp.StatusBadRequest)
+		ctx.Write([]byte(`{"err":"invalid payload"}`))
+		return
+	}
+
+	epochHandler := utils.GetEpochHandlerByID(proof.EpochIndex)
+	if epochHandler == nil {
+		ctx.SetStatusCode(fasthttp.StatusNotFound)
+		ctx.Write([]byte(`{"err":"epoch not found"}`))
+		return
+	}
+
+	if err := utils.ApplyAggregatedCreatorReinstatementProof(&proof, epochHandler); err != nil {
+		ctx.SetStatusCode(fasthttp.StatusConflict)
+		ctx.Write([]byte(fmt.Sprintf(`{"err":"%s"}`, err.Error())))
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.Write([]byte(`{"status":"OK"}`))
+}
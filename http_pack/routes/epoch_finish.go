@@ -0,0 +1,145 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/modulrcloud/modulr-anchors-core/globals"
+	"github.com/modulrcloud/modulr-anchors-core/handlers"
+	"github.com/modulrcloud/modulr-anchors-core/structures"
+	"github.com/modulrcloud/modulr-anchors-core/utils"
+
+	"github.com/valyala/fasthttp"
+)
+
+// RequestEpochFinishVote is used by anchors to collect signatures of quorum for the summary of epoch whose time is over
+func RequestEpochFinishVote(ctx *fasthttp.RequestCtx) {
+
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")
+	ctx.SetContentType("application/json")
+
+	if !ctx.IsPost() {
+		ctx.SetStatusCode(fasthttp.StatusMethodNotAllowed)
+		ctx.Write([]byte(`{"err":"method not allowed"}`))
+		return
+	}
+
+	var req structures.EpochFinishVoteRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		ctx.SetStatusCode(fasthttp.StatusBadRequest)
+		ctx.Write([]byte(`{"err":"invalid payload"}`))
+		return
+	}
+
+	epochHandler := utils.GetEpochHandlerByID(req.Summary.EpochIndex)
+	if epochHandler == nil {
+		ctx.SetStatusCode(fasthttp.StatusNotFound)
+		ctx.Write([]byte(`{"err":"epoch not found"}`))
+		return
+	}
+
+	handlers.APPROVEMENT_THREAD_METADATA.RWMutex.RLock()
+	epochIsOver := utils.EpochIsOver(epochHandler, &handlers.APPROVEMENT_THREAD_METADATA.Handler)
+	handlers.APPROVEMENT_THREAD_METADATA.RWMutex.RUnlock()
+
+	if !epochIsOver {
+		ctx.SetStatusCode(fasthttp.StatusConflict)
+		ctx.Write([]byte(`{"err":"epoch is not finished yet"}`))
+		return
+	}
+
+	if !slices.Contains(epochHandler.Quorum, globals.CONFIGURATION.PublicKey) {
+		ctx.SetStatusCode(fasthttp.StatusForbidden)
+		ctx.Write([]byte(`{"err":"not a quorum member"}`))
+		return
+	}
+
+	response := utils.ProcessEpochFinishVote(&req.Summary, epochHandler)
+
+	switch response.Status {
+	case "OK":
+		ctx.SetStatusCode(fasthttp.StatusOK)
+	case "UPGRADE", "REFUSED":
+		ctx.SetStatusCode(fasthttp.StatusConflict)
+	default:
+		ctx.SetStatusCode(fasthttp.StatusBadRequest)
+	}
+
+	payload, _ := json.Marshal(response)
+	ctx.Write(payload)
+}
+
+// AcceptAggregatedEpochFinishProof stores the epoch finish proof collected by another anchor
+func AcceptAggregatedEpochFinishProof(ctx *fasthttp.RequestCtx) {
+
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")
+	ctx.SetContentType("application/json")
+
+	if !ctx.IsPost() {
+		ctx.SetStatusCode(fasthttp.StatusMethodNotAllowed)
+		ctx.Write([]byte(`{"err":"method not allowed"}`))
+		return
+	}
+
+	var proof structures.AggregatedEpochFinishProof
+	if err := json.Unmarshal(ctx.PostBody(), &proof); err != nil {
+		ctx.SetStatusCode(fasthttp.StatusBadRequest)
+		ctx.Write([]byte(`{"err":"invalid payload"}`))
+		return
+	}
+
+	epochHandler := utils.GetEpochHandlerByID(proof.Summary.EpochIndex)
+	if epochHandler == nil {
+		ctx.SetStatusCode(fasthttp.StatusNotFound)
+		ctx.Write([]byte(`{"err":"epoch not found"}`))
+		return
+	}
+
+	if err := utils.VerifyAggregatedEpochFinishProof(&proof, epochHandler); err != nil {
+		ctx.SetStatusCode(fasthttp.StatusBadRequest)
+		ctx.Write([]byte(fmt.Sprintf(`{"err":"%s"}`, err.Error())))
+		return
+	}
+
+	if !utils.HasAggregatedEpochFinishProof(proof.Summary.EpochIndex) {
+		if err := utils.StoreAggregatedEpochFinishProof(proof); err != nil {
+			ctx.SetStatusCode(fasthttp.StatusInternalServerError)
+			ctx.Write([]byte(`{"err":"failed to store proof"}`))
+			return
+		}
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.Write([]byte(`{"status":"OK"}`))
+}
+
+func GetAggregatedEpochFinishProof(ctx *fasthttp.RequestCtx) {
+
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")
+	ctx.SetContentType("application/json")
+
+	epochIndexRaw, _ := ctx.UserValue("epochIndex").(string)
+
+	epochIndex, err := strconv.Atoi(epochIndexRaw)
+
+	if err != nil || epochIndex < 0 {
+		ctx.SetStatusCode(fasthttp.StatusBadRequest)
+		ctx.Write([]byte(`{"err": "Invalid value"}`))
+		return
+	}
+
+	proof, err := utils.LoadAggregatedEpochFinishProof(epochIndex)
+
+	if err != nil {
+		ctx.SetStatusCode(fasthttp.StatusNotFound)
+		ctx.Write([]byte(`{"err": "Not found"}`))
+		return
+	}
+
+	payload, _ := json.Marshal(proof)
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.Write(payload)
+}
@@ -2,6 +2,7 @@ package routes
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/modulrcloud/modulr-anchors-core/globals"
@@ -35,34 +36,49 @@ func AcceptAggregatedLeaderFinalizationProof(ctx *fasthttp.RequestCtx) {
 		return
 	}
 
-	accepted := 0
+	// Each proof is verified separately, so one bad proof doesn't fail the whole batch
+
+	response := structures.AcceptLeaderFinalizationProofResponse{}
 	for _, proof := range req.LeaderFinalizations {
 		if err := storeAggregatedLeaderFinalizationFromRequest(proof); err != nil {
-			ctx.SetStatusCode(fasthttp.StatusBadRequest)
-			ctx.Write([]byte(fmt.Sprintf(`{"err":"%s"}`, err.Error())))
-			return
+			if errors.Is(err, globals.ErrMempoolStorage) {
+				ctx.SetStatusCode(fasthttp.StatusInternalServerError)
+				ctx.Write([]byte(fmt.Sprintf(`{"err":"%s"}`, err.Error())))
+				return
+			}
+			response.Rejected = append(response.Rejected, structures.LeaderFinalizationProofReject{
+				EpochIndex: proof.EpochIndex,
+				Leader:     proof.Leader,
+				Index:      proof.VotingStat.Index,
+				Reason:     err.Error(),
+			})
+			continue
 		}
-		accepted++
+		response.Accepted++
 	}
 
 	ctx.SetStatusCode(fasthttp.StatusOK)
-	payload, _ := json.Marshal(structures.AcceptAnchorRotationProofResponse{Accepted: accepted})
+	payload, _ := json.Marshal(response)
 	ctx.Write(payload)
 }
 
 func storeAggregatedLeaderFinalizationFromRequest(proof structures.AggregatedLeaderFinalizationProof) error {
 
-	if proof.VotingStat.Index < 0 || proof.VotingStat.Hash == "" {
-		return fmt.Errorf("invalid voting stat")
+	epochHandler := utils.GetEpochHandlerByID(proof.EpochIndex)
+
+	if epochHandler == nil {
+		return fmt.Errorf("epoch %d is not tracked", proof.EpochIndex)
 	}
 
-	if len(proof.Signatures) == 0 {
-		return fmt.Errorf("missing signatures")
+	if err := utils.VerifyAggregatedLeaderFinalizationProof(&proof, epochHandler); err != nil {
+		return err
 	}
 
 	if existing, err := utils.LoadAggregatedLeaderFinalizationProof(proof.EpochIndex, proof.Leader); err == nil {
 		if existing.VotingStat.Index >= proof.VotingStat.Index && existing.VotingStat.Hash == proof.VotingStat.Hash {
-			globals.MEMPOOL.AddAggregatedLeaderFinalizationProof(existing)
+			if !utils.IsAggregatedLeaderFinalizationProofIncluded(&existing) {
+				return globals.MEMPOOL.AddAggregatedLeaderFinalizationProof(existing)
+			}
 			return nil
 		}
 	}
@@ -71,6 +87,8 @@ func storeAggregatedLeaderFinalizationFromRequest(proof structures.AggregatedLea
 		return fmt.Errorf("store leader finalization proof: %w", err)
 	}
 
-	globals.MEMPOOL.AddAggregatedLeaderFinalizationProof(proof)
+	if !utils.IsAggregatedLeaderFinalizationProofIncluded(&proof) {
+		return globals.MEMPOOL.AddAggregatedLeaderFinalizationProof(proof)
+	}
 	return nil
 }
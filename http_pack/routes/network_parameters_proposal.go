@@ -0,0 +1,217 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/modulrcloud/modulr-anchors-core/cryptography"
+	"github.com/modulrcloud/modulr-anchors-core/globals"
+	"github.com/modulrcloud/modulr-anchors-core/handlers"
+	"github.com/modulrcloud/modulr-anchors-core/structures"
+	"github.com/modulrcloud/modulr-anchors-core/utils"
+
+	"github.com/valyala/fasthttp"
+)
+
+// ProposeNetworkParameters is an operator route (available only from localhost) to propose new network parameters.
+// Our node collects signatures of the current epoch quorum and puts the aggregated proof to mempool to include it to our blocks
+func ProposeNetworkParameters(ctx *fasthttp.RequestCtx) {
+
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")
+	ctx.SetContentType("application/json")
+
+	if !ctx.IsPost() {
+		ctx.SetStatusCode(fasthttp.StatusMethodNotAllowed)
+		ctx.Write([]byte(`{"err":"method not allowed"}`))
+		return
+	}
+
+	if !ctx.RemoteIP().IsLoopback() {
+		ctx.SetStatusCode(fasthttp.StatusForbidden)
+		ctx.Write([]byte(`{"err":"route is available only from localhost"}`))
+		return
+	}
+
+	var parameters structures.NetworkParameters
+	if err := json.Unmarshal(ctx.PostBody(), &parameters); err != nil {
+		ctx.SetStatusCode(fasthttp.StatusBadRequest)
+		ctx.Write([]byte(`{"err":"invalid payload"}`))
+		return
+	}
+
+	handlers.APPROVEMENT_THREAD_METADATA.RWMutex.RLock()
+	epochHandler := handlers.APPROVEMENT_THREAD_METADATA.Handler.GetEpochHandler()
+	handlers.APPROVEMENT_THREAD_METADATA.RWMutex.RUnlock()
+
+	proposal := structures.NetworkParametersProposal{
+		EpochIndex: epochHandler.Id,
+		Proposer:   globals.CONFIGURATION.PublicKey,
+		Parameters: parameters,
+	}
+
+	proposal.ProposerSignature = cryptography.GenerateSignature(globals.CONFIGURATION.PrivateKey, utils.GetNetworkParametersProposalSigningData(&proposal))
+
+	if err := utils.ValidateNetworkParametersProposal(&proposal, &epochHandler); err != nil {
+		ctx.SetStatusCode(fasthttp.StatusBadRequest)
+		ctx.Write([]byte(fmt.Sprintf(`{"err":"%s"}`, err.Error())))
+		return
+	}
+
+	proof := structures.AggregatedNetworkParametersProof{Proposal: proposal, Signatures: collectNetworkParametersVotes(&proposal, &epochHandler)}
+
+	// Other operators approve the proposal by hash, so it's returned to be shared with them
+
+	if err := utils.VerifyAggregatedNetworkParametersProof(&proof, &epochHandler); err != nil {
+		ctx.SetStatusCode(fasthttp.StatusConflict)
+		ctx.Write([]byte(fmt.Sprintf(`{"err":"%s","proposalHash":"%s"}`, err.Error(), utils.GetNetworkParametersProposalHash(&proposal))))
+		return
+	}
+
+	if err := globals.MEMPOOL.AddAggregatedNetworkParametersProof(proof); err != nil {
+		ctx.SetStatusCode(fasthttp.StatusInternalServerError)
+		ctx.Write([]byte(fmt.Sprintf(`{"err":"%s"}`, err.Error())))
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	payload, _ := json.Marshal(proof)
+	ctx.Write(payload)
+}
+
+func collectNetworkParametersVotes(proposal *structures.NetworkParametersProposal, epochHandler *structures.EpochDataHandler) map[string]string {
+
+	dataToSign := utils.GetNetworkParametersProposalSigningData(proposal)
+	requestBody, _ := json.Marshal(proposal)
+	quorumWeights := utils.GetQuorumWeights(epochHandler)
+	majority := utils.GetQuorumMajorityByWeights(quorumWeights)
+	signatures := make(map[string]string)
+
+	if _, inQuorum := quorumWeights[globals.CONFIGURATION.PublicKey]; inQuorum {
+		signatures[globals.CONFIGURATION.PublicKey] = cryptography.GenerateSignature(globals.CONFIGURATION.PrivateKey, dataToSign)
+	}
+
+	for _, member := range utils.GetQuorumUrlsAndPubkeys(epochHandler) {
+		if utils.GetSignaturesWeight(quorumWeights, signatures) >= majority {
+			break
+		}
+		if member.PubKey == globals.CONFIGURATION.PublicKey || member.Url == "" {
+			continue
+		}
+		endpoint := strings.TrimRight(member.Url, "/") + "/request_network_parameters_vote"
+		body, status, err := utils.PostJSON(endpoint, requestBody)
+		if err != nil || status != fasthttp.StatusOK {
+			continue
+		}
+		var response structures.NetworkParametersVoteResponse
+		if err := json.Unmarshal(body, &response); err != nil || response.Status != "OK" {
+			continue
+		}
+		if cryptography.VerifySignature(dataToSign, member.PubKey, response.Signature) {
+			signatures[member.PubKey] = response.Signature
+		}
+	}
+
+	return signatures
+}
+
+type networkParametersApproval struct {
+	ProposalHash string `json:"proposalHash"`
+}
+
+// ApproveNetworkParametersProposal is an operator route (available only from localhost) to allow our node to vote for the proposal with given hash
+func ApproveNetworkParametersProposal(ctx *fasthttp.RequestCtx) {
+
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")
+	ctx.SetContentType("application/json")
+
+	if !ctx.IsPost() {
+		ctx.SetStatusCode(fasthttp.StatusMethodNotAllowed)
+		ctx.Write([]byte(`{"err":"method not allowed"}`))
+		return
+	}
+
+	if !ctx.RemoteIP().IsLoopback() {
+		ctx.SetStatusCode(fasthttp.StatusForbidden)
+		ctx.Write([]byte(`{"err":"route is available only from localhost"}`))
+		return
+	}
+
+	var approval networkParametersApproval
+	if err := json.Unmarshal(ctx.PostBody(), &approval); err != nil || approval.ProposalHash == "" {
+		ctx.SetStatusCode(fasthttp.StatusBadRequest)
+		ctx.Write([]byte(`{"err":"invalid payload"}`))
+		return
+	}
+
+	utils.ApproveNetworkParametersProposal(approval.ProposalHash)
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.Write([]byte(`{"status":"OK"}`))
+}
+
+// RequestNetworkParametersVote returns our signature for the proposal if it was sent by its proposer and approved by node operator
+func RequestNetworkParametersVote(ctx *fasthttp.RequestCtx) {
+
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")
+	ctx.SetContentType("application/json")
+
+	if !ctx.IsPost() {
+		ctx.SetStatusCode(fasthttp.StatusMethodNotAllowed)
+		ctx.Write([]byte(`{"err":"method not allowed"}`))
+		return
+	}
+
+	var proposal structures.NetworkParametersProposal
+	if err := json.Unmarshal(ctx.PostBody(), &proposal); err != nil {
+		ctx.SetStatusCode(fasthttp.StatusBadRequest)
+		ctx.Write([]byte(`{"err":"invalid payload"}`))
+		return
+	}
+
+	// Caller was authenticated by AuthenticatedPeer
+
+	if proposal.Proposer != string(ctx.Request.Header.Peek(utils.PEER_PUBKEY_HEADER)) {
+		ctx.SetStatusCode(fasthttp.StatusForbidden)
+		ctx.Write([]byte(`{"err":"proposal should be sent by its proposer"}`))
+		return
+	}
+
+	epochHandler := utils.GetEpochHandlerByID(proposal.EpochIndex)
+	if epochHandler == nil {
+		ctx.SetStatusCode(fasthttp.StatusNotFound)
+		ctx.Write([]byte(`{"err":"epoch not found"}`))
+		return
+	}
+
+	if !slices.Contains(epochHandler.Quorum, globals.CONFIGURATION.PublicKey) {
+		ctx.SetStatusCode(fasthttp.StatusConflict)
+		ctx.Write([]byte(`{"err":"not a quorum member"}`))
+		return
+	}
+
+	if err := utils.ValidateNetworkParametersProposal(&proposal, epochHandler); err != nil {
+		ctx.SetStatusCode(fasthttp.StatusBadRequest)
+		payload, _ := json.Marshal(structures.NetworkParametersVoteResponse{Status: "ERROR", Message: err.Error()})
+		ctx.Write(payload)
+		return
+	}
+
+	proposalHash := utils.GetNetworkParametersProposalHash(&proposal)
+
+	if !utils.IsNetworkParametersProposalApproved(proposalHash) {
+		utils.LogWithTime(fmt.Sprintf("Network parameters proposal %s of %s (epoch %d) is waiting for approval of operator", proposalHash, proposal.Proposer, proposal.EpochIndex), utils.YELLOW_COLOR)
+		ctx.SetStatusCode(fasthttp.StatusForbidden)
+		payload, _ := json.Marshal(structures.NetworkParametersVoteResponse{Status: "ERROR", Message: "proposal " + proposalHash + " is not approved by operator"})
+		ctx.Write(payload)
+		return
+	}
+
+	payload, _ := json.Marshal(structures.NetworkParametersVoteResponse{
+		Status:    "OK",
+		Signature: cryptography.GenerateSignature(globals.CONFIGURATION.PrivateKey, utils.GetNetworkParametersProposalSigningData(&proposal)),
+	})
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.Write(payload)
+}
@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"encoding/json"
+
+	"github.com/modulrcloud/modulr-anchors-core/utils"
+
+	"github.com/valyala/fasthttp"
+)
+
+// GetRateLimitsMetrics returns counters of requests, messages and connections rejected by limits since start
+func GetRateLimitsMetrics(ctx *fasthttp.RequestCtx) {
+
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")
+	ctx.SetContentType("application/json")
+
+	payload, err := json.Marshal(utils.RATE_LIMITS_METRICS.Counters())
+
+	if err != nil {
+		ctx.SetStatusCode(fasthttp.StatusInternalServerError)
+		ctx.Write([]byte(`{"err": "Failed to marshal metrics"}`))
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.Write(payload)
+
+}
@@ -75,7 +75,7 @@ func RequestAnchorRotationProof(ctx *fasthttp.RequestCtx) {
 		respondWithUpgrade(ctx, currentStat)
 		return
 	case proposal.Index == currentStat.Index:
-		handleMatchingProposal(ctx, currentStat, proposal, epochHandler)
+		handleMatchingProposal(ctx, currentStat, proposal, req.Creator, epochHandler)
 		return
 	default:
 		handleUpgradeProposal(ctx, currentStat, proposal, req.EpochIndex, req.Creator, epochHandler)
@@ -93,7 +93,7 @@ func respondWithUpgrade(ctx *fasthttp.RequestCtx, stat structures.VotingStat) {
 	ctx.Write(payload)
 }
 
-func handleMatchingProposal(ctx *fasthttp.RequestCtx, current, proposal structures.VotingStat, epochHandler *structures.EpochDataHandler) {
+func handleMatchingProposal(ctx *fasthttp.RequestCtx, current, proposal structures.VotingStat, creator string, epochHandler *structures.EpochDataHandler) {
 	if current.Index < 0 || current.Hash == "" {
 		ctx.SetStatusCode(fasthttp.StatusConflict)
 		ctx.Write([]byte(`{"err":"no finalized blocks recorded"}`))
@@ -106,7 +106,7 @@ func handleMatchingProposal(ctx *fasthttp.RequestCtx, current, proposal structur
 		return
 	}
 
-	respondWithSignature(ctx, current, epochHandler)
+	respondWithSignature(ctx, creator, current, epochHandler)
 }
 
 func handleUpgradeProposal(ctx *fasthttp.RequestCtx, current, proposal structures.VotingStat, epochIndex int, creator string, epochHandler *structures.EpochDataHandler) {
@@ -123,12 +123,18 @@ func handleUpgradeProposal(ctx *fasthttp.RequestCtx, current, proposal structure
 		return
 	}
 
-	respondWithSignature(ctx, proposal, epochHandler)
+	respondWithSignature(ctx, creator, proposal, epochHandler)
 }
 
-func respondWithSignature(ctx *fasthttp.RequestCtx, stat structures.VotingStat, epochHandler *structures.EpochDataHandler) {
+func respondWithSignature(ctx *fasthttp.RequestCtx, creator string, stat structures.VotingStat, epochHandler *structures.EpochDataHandler) {
+	if err := utils.CheckAndRecordSignature(utils.SIGNED_ANCHOR_ROTATION_PROOF, epochHandler.Id, creator, stat.Index, stat.Hash); err != nil {
+		ctx.SetStatusCode(fasthttp.StatusConflict)
+		ctx.Write([]byte(fmt.Sprintf(`{"err":"slashing protection: %s"}`, err.Error())))
+		return
+	}
+
 	epochFullID := epochHandler.Hash + "#" + strconv.Itoa(epochHandler.Id)
-	dataToSign := strings.Join([]string{stat.Afp.PrevBlockHash, stat.Afp.BlockId, stat.Afp.BlockHash, epochFullID}, ":")
+	dataToSign := utils.GetAnchorRotationProofSigningData(epochHandler.SigningPayloadVersion, &stat, epochFullID)
 	signature := cryptography.GenerateSignature(globals.CONFIGURATION.PrivateKey, dataToSign)
 	payload, _ := json.Marshal(structures.AnchorRotationProofResponse{
 		Status:     "OK",
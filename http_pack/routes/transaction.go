@@ -0,0 +1,66 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/modulrcloud/modulr-anchors-core/globals"
+	"github.com/modulrcloud/modulr-anchors-core/handlers"
+	"github.com/modulrcloud/modulr-anchors-core/structures"
+	"github.com/modulrcloud/modulr-anchors-core/utils"
+
+	"github.com/valyala/fasthttp"
+)
+
+// AcceptTransaction verifies signed anchor transaction and puts it to mempool to include to our blocks.
+// Transactions are included only to blocks of their creator, so only transactions of our node are accepted
+func AcceptTransaction(ctx *fasthttp.RequestCtx) {
+
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")
+	ctx.SetContentType("application/json")
+
+	if !ctx.IsPost() {
+		ctx.SetStatusCode(fasthttp.StatusMethodNotAllowed)
+		ctx.Write([]byte(`{"err":"method not allowed"}`))
+		return
+	}
+
+	var tx structures.AnchorTransaction
+	if err := json.Unmarshal(ctx.PostBody(), &tx); err != nil {
+		ctx.SetStatusCode(fasthttp.StatusBadRequest)
+		ctx.Write([]byte(`{"err":"invalid payload"}`))
+		return
+	}
+
+	if tx.Creator != globals.CONFIGURATION.PublicKey {
+		ctx.SetStatusCode(fasthttp.StatusBadRequest)
+		ctx.Write([]byte(`{"err":"transaction should be sent to the node of its creator"}`))
+		return
+	}
+
+	handlers.APPROVEMENT_THREAD_METADATA.RWMutex.RLock()
+	epochHandler := handlers.APPROVEMENT_THREAD_METADATA.Handler.GetEpochHandler()
+	handlers.APPROVEMENT_THREAD_METADATA.RWMutex.RUnlock()
+
+	if err := utils.VerifyAnchorTransaction(&tx, &epochHandler); err != nil {
+		ctx.SetStatusCode(fasthttp.StatusBadRequest)
+		ctx.Write([]byte(fmt.Sprintf(`{"err":"%s"}`, err.Error())))
+		return
+	}
+
+	if err := globals.MEMPOOL.AddTransaction(tx, globals.CONFIGURATION.TxsMempoolSize); err != nil {
+		if errors.Is(err, globals.ErrMempoolStorage) {
+			ctx.SetStatusCode(fasthttp.StatusInternalServerError)
+		} else if errors.Is(err, globals.ErrMempoolIsFull) {
+			ctx.SetStatusCode(fasthttp.StatusServiceUnavailable)
+		} else {
+			ctx.SetStatusCode(fasthttp.StatusConflict)
+		}
+		ctx.Write([]byte(fmt.Sprintf(`{"err":"%s"}`, err.Error())))
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.Write([]byte(`{"status":"OK"}`))
+}
@@ -2,6 +2,7 @@ package http_pack
 
 import (
 	"fmt"
+	"net"
 	"strconv"
 
 	"github.com/modulrcloud/modulr-anchors-core/globals"
@@ -20,14 +21,46 @@ func createRouter() fasthttp.RequestHandler {
 	r.GET("/block/{id}", routes.GetBlockById)
 	r.GET("/aggregated_finalization_proof/{blockId}", routes.GetAggregatedFinalizationProof)
 
+	// Evidences that block creators signed two different blocks with the same id
+	r.GET("/block_equivocation_evidences/{epochIndex}", routes.GetBlockEquivocationEvidences)
+
+	// Evidences that quorum members voted for two different hashes of the same block id
+	r.GET("/double_vote_evidences/{epochIndex}", routes.GetDoubleVoteEvidences)
+
+	// Routes wrapped with AuthenticatedPeer accept only requests signed by registered anchors (see docs/peer_authentication.md)
+
 	// Route to request ARP (anchor rotation proof), then aggregated them and get AARP(Aggregated Anchor Rotation Proof)
-	r.POST("/request_anchor_rotation_proof", routes.RequestAnchorRotationProof)
+	r.POST("/request_anchor_rotation_proof", AuthenticatedPeer(routes.RequestAnchorRotationProof))
 	// Route to accept AARP, put to mempool and include to blocks
-	r.POST("/accept_aggregated_anchor_rotation_proof", routes.AcceptAggregatedAnchorRotationProofs)
+	r.POST("/accept_aggregated_anchor_rotation_proof", AuthenticatedPeer(routes.AcceptAggregatedAnchorRotationProofs))
 
 	// Route to accept ALFP (Aggregated Leader Finalization Proof) from modulr-core logic, put to mempool and include to blocks
 	r.POST("/accept_aggregated_leader_finalization_proof", routes.AcceptAggregatedLeaderFinalizationProof)
 
+	// Route to accept signed transactions of anchors, put to mempool and include to blocks
+	r.POST("/transaction", routes.AcceptTransaction)
+
+	// Route to accept signed requests of anchors to join/leave the registry, put to mempool and include to blocks
+	r.POST("/accept_anchor_membership_requests", routes.AcceptAnchorMembershipRequests)
+
+	// Operator routes to propose new network parameters and to approve proposals of other anchors by hash,
+	// and route for quorum members to vote for such proposals
+	r.POST("/propose_network_parameters", routes.ProposeNetworkParameters)
+	r.POST("/approve_network_parameters_proposal", routes.ApproveNetworkParametersProposal)
+	r.POST("/request_network_parameters_vote", AuthenticatedPeer(routes.RequestNetworkParametersVote))
+
+	// Reinstatement of creators wrongly disabled by health checker - collect quorum signatures and accept aggregated proof
+	r.POST("/request_creator_reinstatement", AuthenticatedPeer(routes.RequestCreatorReinstatement))
+	r.POST("/accept_creator_reinstatement_proof", AuthenticatedPeer(routes.AcceptAggregatedCreatorReinstatementProof))
+
+	// Epoch finish protocol - vote for the summary of closed epoch, accept aggregated proof and read it (used by modulr-core as epoch cut-off)
+	r.POST("/request_epoch_finish_vote", AuthenticatedPeer(routes.RequestEpochFinishVote))
+	r.POST("/accept_aggregated_epoch_finish_proof", AuthenticatedPeer(routes.AcceptAggregatedEpochFinishProof))
+	r.GET("/aggregated_epoch_finish_proof/{epochIndex}", routes.GetAggregatedEpochFinishProof)
+
+	// Counters of requests, messages and connections rejected by rate limits
+	r.GET("/rate_limits_metrics", routes.GetRateLimitsMetrics)
+
 	return r.Handler
 }
 
@@ -37,7 +70,22 @@ func CreateHTTPServer() {
 
 	utils.LogWithTime(fmt.Sprintf("Server is starting at http://%s ...✅", serverAddr), utils.CYAN_COLOR)
 
-	if err := fasthttp.ListenAndServe(serverAddr, createRouter()); err != nil {
+	limits := globals.CONFIGURATION.RateLimits
+
+	server := &fasthttp.Server{
+		Handler:            rateLimited(createRouter()),
+		MaxRequestBodySize: limits.HttpMaxBodySizeInBytes,
+		ErrorHandler:       handleServerError,
+	}
+
+	listener, err := net.Listen("tcp4", serverAddr)
+
+	if err != nil {
+		utils.LogWithTime(fmt.Sprintf("Error in server: %s", err), utils.RED_COLOR)
+		return
+	}
+
+	if err := server.Serve(&limitedListener{Listener: listener, limiter: utils.NewConnectionsLimiter(limits.HttpMaxConnectionsPerIp)}); err != nil {
 		utils.LogWithTime(fmt.Sprintf("Error in server: %s", err), utils.RED_COLOR)
 	}
 }
@@ -15,6 +15,8 @@ import (
 
 func main() {
 
+	globals.CHAINDATA_PATH = globals.ResolveChaindataPath()
+
 	//_____________________________________________________CONFIG_PROCESS____________________________________________________
 
 	configsRawJson, readError := os.ReadFile(globals.CHAINDATA_PATH + "/configs.json")
@@ -31,6 +33,8 @@ func main() {
 
 	}
 
+	globals.CONFIGURATION.RateLimits = globals.CONFIGURATION.RateLimits.WithDefaults()
+
 	//_____________________________________________________READ GENESIS______________________________________________________
 
 	genesisRawJson, readError := os.ReadFile(globals.CHAINDATA_PATH + "/genesis.json")
@@ -47,6 +51,14 @@ func main() {
 
 	}
 
+	// Maintenance commands (e.g. slashing-protection export) run instead of the node
+
+	if len(os.Args) > 1 {
+
+		os.Exit(runCommand(os.Args[1:]))
+
+	}
+
 	currentUser, _ := user.Current()
 
 	utils.PrintBanner()
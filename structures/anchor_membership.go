@@ -0,0 +1,19 @@
+package structures
+
+const (
+	ANCHOR_MEMBERSHIP_JOIN  = "JOIN"
+	ANCHOR_MEMBERSHIP_LEAVE = "LEAVE"
+)
+
+// AnchorMembershipRequest is a request of anchor to join or leave the anchors registry.
+// Request is signed by the anchor itself, carried in block extra data and applied at the end of EpochIndex epoch
+type AnchorMembershipRequest struct {
+	Type       string        `json:"type"`
+	EpochIndex int           `json:"epochIndex"`
+	Anchor     AnchorStorage `json:"anchor"`
+	Signature  string        `json:"signature"`
+}
+
+type AcceptAnchorMembershipRequestsRequest struct {
+	MembershipRequests []AnchorMembershipRequest `json:"membershipRequests"`
+}
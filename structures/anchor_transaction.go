@@ -0,0 +1,11 @@
+package structures
+
+// AnchorTransaction is a generic signed payload of registered anchor. Transactions are carried in blocks besides proofs,
+// so anchors can share application data. Nonce of each creator should grow, finalized nonces can't be used again
+type AnchorTransaction struct {
+	Creator   string `json:"creator"`
+	Nonce     uint64 `json:"nonce"`
+	Type      string `json:"type"`
+	Payload   string `json:"payload"`
+	Signature string `json:"sig"`
+}
@@ -0,0 +1,12 @@
+package structures
+
+import "encoding/json"
+
+// BlockEquivocationEvidence proves that creator signed two different blocks with the same epoch:creator:index.
+// Blocks are kept in their JSON form so anyone can recompute hashes and verify signatures
+type BlockEquivocationEvidence struct {
+	EpochIndex  int             `json:"epochIndex"`
+	BlockId     string          `json:"blockId"`
+	FirstBlock  json.RawMessage `json:"firstBlock"`
+	SecondBlock json.RawMessage `json:"secondBlock"`
+}
@@ -0,0 +1,21 @@
+package structures
+
+import "encoding/json"
+
+// ChaindataSnapshotHeader describes the snapshot archive. It's written before the records, so the importer
+// can check the network and schema and get epoch handlers required to verify AFPs before anything is restored
+type ChaindataSnapshotHeader struct {
+	FormatVersion  int    `json:"formatVersion"`
+	SchemaVersion  int    `json:"schemaVersion"`
+	NetworkId      string `json:"networkId"`
+	ExporterPubkey string `json:"exporterPubkey"`
+	CreatedAt      int64  `json:"createdAt"`
+
+	// Raw AT record of APPROVEMENT_THREAD_METADATA
+	ApprovementThread json.RawMessage `json:"approvementThread"`
+
+	// Epoch index => epoch handler
+	EpochHandlers map[int]EpochDataHandler `json:"epochHandlers"`
+
+	Records int `json:"records"`
+}
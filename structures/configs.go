@@ -1,5 +1,7 @@
 package structures
 
+import "maps"
+
 type NodeLevelConfig struct {
 	PublicKey               string            `json:"PUBLIC_KEY"`
 	PrivateKey              string            `json:"PRIVATE_KEY"`
@@ -13,4 +15,122 @@ type NodeLevelConfig struct {
 	Port                    int               `json:"PORT"`
 	WebSocketInterface      string            `json:"WEBSOCKET_INTERFACE"`
 	WebSocketPort           int               `json:"WEBSOCKET_PORT"`
+
+	// BLS key for networks with BLS finalization proofs, required in such networks (see `modulr-anchor bls keygen`)
+	BlsPrivateKey string `json:"BLS_PRIVATE_KEY,omitempty"`
+
+	// Limits of HTTP and websocket servers to protect node from flooding. Zero (or missing) values are set to defaults
+	RateLimits RateLimitsConfig `json:"RATE_LIMITS"`
+
+	// What to do with data of old epochs. Default is to keep everything
+	Retention RetentionConfig `json:"RETENTION"`
+}
+
+// TokenBucketConfig allows BURST requests at once, then RATE requests per second. Negative RATE disables the limit
+type TokenBucketConfig struct {
+	Rate  float64 `json:"RATE"`
+	Burst int     `json:"BURST"`
+}
+
+type RateLimitsConfig struct {
+	HttpMaxBodySizeInBytes  int                          `json:"HTTP_MAX_BODY_SIZE_IN_BYTES"`  // negative - fasthttp default (4 MB)
+	HttpMaxConnectionsPerIp int                          `json:"HTTP_MAX_CONNECTIONS_PER_IP"`  // concurrent connections, negative - no limit
+	HttpPerIp               TokenBucketConfig            `json:"HTTP_PER_IP"`                  // all requests of IP
+	HttpPerRoute            map[string]TokenBucketConfig `json:"HTTP_PER_ROUTE"`               // requests of IP to route, keys ending with "/" match by prefix
+	WsMaxMessageSizeInBytes int                          `json:"WS_MAX_MESSAGE_SIZE_IN_BYTES"` // negative - gws default (16 MB)
+	WsMaxConnectionsPerIp   int                          `json:"WS_MAX_CONNECTIONS_PER_IP"`    // concurrent connections, negative - no limit
+	WsPerIp                 TokenBucketConfig            `json:"WS_PER_IP"`                    // all messages of IP
+	WsPerMessageType        map[string]TokenBucketConfig `json:"WS_PER_MESSAGE_TYPE"`          // messages of IP with the route, e.g. get_finalization_proof
+}
+
+// DefaultRateLimits returns limits used for zero (or missing) values of config.
+// They are high enough for local testnets where all anchors share 127.0.0.1
+func DefaultRateLimits() RateLimitsConfig {
+
+	return RateLimitsConfig{
+		HttpMaxBodySizeInBytes:  4 * 1024 * 1024,
+		HttpMaxConnectionsPerIp: 256,
+		HttpPerIp:               TokenBucketConfig{Rate: 200, Burst: 400},
+		HttpPerRoute: map[string]TokenBucketConfig{
+			"/transaction":                       {Rate: 50, Burst: 100},
+			"/accept_anchor_membership_requests": {Rate: 5, Burst: 10},
+			"/propose_network_parameters":        {Rate: 1, Burst: 5},
+			"/request_anchor_rotation_proof":     {Rate: 10, Burst: 20},
+			"/block/":                            {Rate: 50, Burst: 100},
+		},
+		WsMaxMessageSizeInBytes: 16 * 1024 * 1024,
+		WsMaxConnectionsPerIp:   64,
+		WsPerIp:                 TokenBucketConfig{Rate: 500, Burst: 1000},
+		WsPerMessageType: map[string]TokenBucketConfig{
+			"get_finalization_proof": {Rate: 200, Burst: 400},
+			"get_block_with_afp":     {Rate: 100, Burst: 200},
+		},
+	}
+
+}
+
+// WithDefaults returns config where zero (or missing) values are taken from DefaultRateLimits.
+// Routes and message types which are not set in config get the default limits
+func (config RateLimitsConfig) WithDefaults() RateLimitsConfig {
+
+	defaults := DefaultRateLimits()
+
+	if config.HttpMaxBodySizeInBytes == 0 {
+		config.HttpMaxBodySizeInBytes = defaults.HttpMaxBodySizeInBytes
+	}
+
+	if config.HttpMaxConnectionsPerIp == 0 {
+		config.HttpMaxConnectionsPerIp = defaults.HttpMaxConnectionsPerIp
+	}
+
+	if config.HttpPerIp == (TokenBucketConfig{}) {
+		config.HttpPerIp = defaults.HttpPerIp
+	}
+
+	if config.WsMaxMessageSizeInBytes == 0 {
+		config.WsMaxMessageSizeInBytes = defaults.WsMaxMessageSizeInBytes
+	}
+
+	if config.WsMaxConnectionsPerIp == 0 {
+		config.WsMaxConnectionsPerIp = defaults.WsMaxConnectionsPerIp
+	}
+
+	if config.WsPerIp == (TokenBucketConfig{}) {
+		config.WsPerIp = defaults.WsPerIp
+	}
+
+	config.HttpPerRoute = withDefaultBuckets(config.HttpPerRoute, defaults.HttpPerRoute)
+
+	config.WsPerMessageType = withDefaultBuckets(config.WsPerMessageType, defaults.WsPerMessageType)
+
+	return config
+
+}
+
+func withDefaultBuckets(configs, defaults map[string]TokenBucketConfig) map[string]TokenBucketConfig {
+
+	merged := maps.Clone(defaults)
+
+	for key, config := range configs {
+		if config != (TokenBucketConfig{}) {
+			merged[key] = config
+		}
+	}
+
+	return merged
+
+}
+
+// Retention modes
+const (
+	RETENTION_ARCHIVE           = "ARCHIVE"           // keep everything
+	RETENTION_PRUNE             = "PRUNE"             // delete all the data of old epochs
+	RETENTION_PRUNE_KEEP_PROOFS = "PRUNE_KEEP_PROOFS" // delete all the data of old epochs except the final AFP of each creator
+)
+
+type RetentionConfig struct {
+	Mode            string `json:"MODE"`              // ARCHIVE (default), PRUNE or PRUNE_KEEP_PROOFS
+	KeepEpochs      int    `json:"KEEP_EPOCHS"`       // epochs with index < current - KEEP_EPOCHS are pruned. Supported epochs are never pruned
+	BatchSize       int    `json:"BATCH_SIZE"`        // keys deleted per batch, 0 - 1000
+	BatchIntervalMs int64  `json:"BATCH_INTERVAL_MS"` // pause between batches, 0 - 100
 }
@@ -0,0 +1,23 @@
+package structures
+
+// CreatorReinstatementRequest is sent by block creator which was disabled by health checker, but still progresses.
+// Afp proves the block of creator finalized beyond the height where it was considered stalled
+type CreatorReinstatementRequest struct {
+	EpochIndex int                         `json:"epochIndex"`
+	Creator    string                      `json:"creator"`
+	Afp        AggregatedFinalizationProof `json:"afp"`
+}
+
+type CreatorReinstatementResponse struct {
+	Status    string `json:"status"`
+	Message   string `json:"message,omitempty"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// AggregatedCreatorReinstatementProof contains signatures of epoch quorum majority to enable proofs for creator again
+type AggregatedCreatorReinstatementProof struct {
+	EpochIndex int                         `json:"epochIndex"`
+	Creator    string                      `json:"creator"`
+	Afp        AggregatedFinalizationProof `json:"afp"`
+	Signatures map[string]string           `json:"signatures"`
+}
@@ -0,0 +1,20 @@
+package structures
+
+// FinalizationVote is the single signed finalization proof of quorum member
+type FinalizationVote struct {
+	PrevBlockHash string `json:"prevBlockHash"`
+	BlockHash     string `json:"blockHash"`
+	Signature     string `json:"signature"`
+}
+
+// DoubleVoteEvidence proves that quorum member signed finalization proofs for two different hashes of the same block id.
+// It carries everything required to rebuild the signed payloads, so it can be verified without local state
+type DoubleVoteEvidence struct {
+	EpochIndex            int              `json:"epochIndex"`
+	EpochFullID           string           `json:"epochFullId"`
+	SigningPayloadVersion int              `json:"signingPayloadVersion"`
+	Voter                 string           `json:"voter"`
+	BlockId               string           `json:"blockId"`
+	FirstVote             FinalizationVote `json:"firstVote"`
+	SecondVote            FinalizationVote `json:"secondVote"`
+}
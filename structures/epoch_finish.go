@@ -0,0 +1,25 @@
+package structures
+
+// EpochFinishSummary fixes the last finalized block of each creator of the closed epoch
+type EpochFinishSummary struct {
+	EpochIndex  int                   `json:"epochIndex"`
+	VotingStats map[string]VotingStat `json:"votingStats"` // creator => last finalized block with its AFP
+}
+
+type EpochFinishVoteRequest struct {
+	Summary EpochFinishSummary `json:"summary"`
+}
+
+type EpochFinishVoteResponse struct {
+	Status      string                `json:"status"`
+	Message     string                `json:"message,omitempty"`
+	Signature   string                `json:"signature,omitempty"`
+	VotingStats map[string]VotingStat `json:"votingStats,omitempty"` // fresher stats of voter in case of UPGRADE status
+}
+
+// AggregatedEpochFinishProof contains signatures of epoch quorum majority for the summary.
+// It's the authoritative cut-off of epoch for modulr-core
+type AggregatedEpochFinishProof struct {
+	Summary    EpochFinishSummary `json:"summary"`
+	Signatures map[string]string  `json:"signatures"`
+}
@@ -6,4 +6,10 @@ type EpochDataHandler struct {
 	AnchorsRegistry []string `json:"anchorsRegistry"`
 	Quorum          []string `json:"quorum"`
 	StartTimestamp  uint64   `json:"startTimestamp"`
+
+	// Version of signing payloads used by all signatures within epoch
+	SigningPayloadVersion int `json:"signingPayloadVersion,omitempty"`
+
+	// Voting weights of quorum members fixed at epoch creation, so later weight changes don't affect proofs of the epoch
+	QuorumWeights map[string]uint64 `json:"quorumWeights,omitempty"`
 }
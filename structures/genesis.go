@@ -1,10 +1,27 @@
 package structures
 
+import "maps"
+
 type Genesis struct {
 	NetworkId                string            `json:"NETWORK_ID"`
 	FirstEpochStartTimestamp uint64            `json:"FIRST_EPOCH_START_TIMESTAMP"`
 	NetworkParameters        NetworkParameters `json:"NETWORK_PARAMETERS"`
 	Anchors                  []AnchorStorage   `json:"ANCHORS"`
+
+	// Format of signatures in aggregated finalization proofs - ED25519 (default) or BLS
+	FinalizationProofsFormat string `json:"FINALIZATION_PROOFS_FORMAT,omitempty"`
+
+	// Ed25519 pubkeys of modulr-core quorum which signs leader finalization proofs. ALFPs are rejected if it's empty
+	ModulrCoreQuorum []string `json:"MODULR_CORE_QUORUM,omitempty"`
+}
+
+const (
+	FINALIZATION_PROOFS_FORMAT_ED25519 = "ED25519"
+	FINALIZATION_PROOFS_FORMAT_BLS     = "BLS"
+)
+
+func (genesis *Genesis) UsesBlsFinalizationProofs() bool {
+	return genesis.FinalizationProofsFormat == FINALIZATION_PROOFS_FORMAT_BLS
 }
 
 type NetworkParameters struct {
@@ -15,6 +32,27 @@ type NetworkParameters struct {
 	TxLimitPerBlock                    int   `json:"TXS_LIMIT_PER_BLOCK"`
 	MaxEpochsToSupport                 int   `json:"MAX_EPOCHS_TO_SUPPORT"`
 	BlockCreatorsHealthCheckIntervalMs int64 `json:"BLOCK_CREATORS_HEALTH_CHECK_INTERVAL_MS"`
+	SigningPayloadVersion              int   `json:"SIGNING_PAYLOAD_VERSION"`
+
+	StallDetectionPolicy StallDetectionPolicy `json:"STALL_DETECTION_POLICY"`
+
+	// Voting weights of anchors set by governance. They override weights of anchors storages (genesis weight or 1)
+	AnchorWeights map[string]uint64 `json:"ANCHOR_WEIGHTS,omitempty"`
+}
+
+// StallDetectionPolicy defines when health checker disables finalization proofs for a creator without progress
+type StallDetectionPolicy struct {
+	StalledObservationsToDisable int   `json:"STALLED_OBSERVATIONS_TO_DISABLE"` // consecutive checks without progress before disabling (0 is treated as 1)
+	EpochStartGracePeriodMs      int64 `json:"EPOCH_START_GRACE_PERIOD_MS"`     // creators are not checked during this period after epoch start
+	LaggingNodeThresholdPercent  int   `json:"LAGGING_NODE_THRESHOLD_PERCENT"`  // if at least this percent of creators look stalled at once - our node lags, nobody is disabled (0 to turn off)
+	DryRun                       bool  `json:"DRY_RUN"`                         // only report creators which would be disabled
+}
+
+func (policy *StallDetectionPolicy) GetStalledObservationsToDisable() int {
+	if policy.StalledObservationsToDisable <= 0 {
+		return 1
+	}
+	return policy.StalledObservationsToDisable
 }
 
 func (src *NetworkParameters) CopyNetworkParameters() NetworkParameters {
@@ -26,6 +64,9 @@ func (src *NetworkParameters) CopyNetworkParameters() NetworkParameters {
 		TxLimitPerBlock:                    src.TxLimitPerBlock,
 		MaxEpochsToSupport:                 src.MaxEpochsToSupport,
 		BlockCreatorsHealthCheckIntervalMs: src.BlockCreatorsHealthCheckIntervalMs,
+		SigningPayloadVersion:              src.SigningPayloadVersion,
+		StallDetectionPolicy:               src.StallDetectionPolicy,
+		AnchorWeights:                      maps.Clone(src.AnchorWeights),
 	}
 }
 
@@ -33,4 +74,17 @@ type AnchorStorage struct {
 	Pubkey       string `json:"pubkey"`
 	AnchorUrl    string `json:"anchorURL"`
 	WssAnchorUrl string `json:"wssAnchorURL"`
+	Weight       uint64 `json:"weight,omitempty"`
+
+	// Required for networks with BLS finalization proofs
+	BlsPubkey            string `json:"blsPubkey,omitempty"`
+	BlsProofOfPossession string `json:"blsProofOfPossession,omitempty"`
+}
+
+// GetVotingWeight returns the anchor weight used in majority checks. Anchors without explicit weight vote with weight 1
+func (storage *AnchorStorage) GetVotingWeight() uint64 {
+	if storage.Weight == 0 {
+		return 1
+	}
+	return storage.Weight
 }
@@ -0,0 +1,22 @@
+package structures
+
+// NetworkParametersProposal is a proposal of anchor to replace network parameters starting from the epoch after EpochIndex.
+// ProposerSignature is a signature of proposer for the proposal signing data
+type NetworkParametersProposal struct {
+	EpochIndex        int               `json:"epochIndex"`
+	Proposer          string            `json:"proposer"`
+	Parameters        NetworkParameters `json:"parameters"`
+	ProposerSignature string            `json:"proposerSignature"`
+}
+
+type NetworkParametersVoteResponse struct {
+	Status    string `json:"status"`
+	Message   string `json:"message,omitempty"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// AggregatedNetworkParametersProof contains signatures of quorum majority of proposal epoch for the proposal
+type AggregatedNetworkParametersProof struct {
+	Proposal   NetworkParametersProposal `json:"proposal"`
+	Signatures map[string]string         `json:"signatures"`
+}
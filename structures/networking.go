@@ -24,3 +24,15 @@ type AcceptAnchorRotationProofResponse struct {
 type AcceptLeaderFinalizationProofRequest struct {
 	LeaderFinalizations []AggregatedLeaderFinalizationProof `json:"leaderFinalizations"`
 }
+
+type AcceptLeaderFinalizationProofResponse struct {
+	Accepted int                             `json:"accepted"`
+	Rejected []LeaderFinalizationProofReject `json:"rejected,omitempty"`
+}
+
+type LeaderFinalizationProofReject struct {
+	EpochIndex int    `json:"epochIndex"`
+	Leader     string `json:"leader"`
+	Index      int    `json:"index"`
+	Reason     string `json:"reason"`
+}
@@ -7,6 +7,10 @@ type AggregatedFinalizationProof struct {
 	BlockId       string            `json:"blockId"`
 	BlockHash     string            `json:"blockHash"`
 	Proofs        map[string]string `json:"proofs"`
+
+	// BLS format - single aggregated signature and hex bitmap of signers (bit i is set for epoch quorum[i])
+	AggregatedSignature string `json:"aggregatedSignature,omitempty"`
+	SignersBitmap       string `json:"signersBitmap,omitempty"`
 }
 
 func (afp *AggregatedFinalizationProof) UnmarshalJSON(data []byte) error {
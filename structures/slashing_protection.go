@@ -0,0 +1,22 @@
+package structures
+
+// SignedEntry is the record of slashing protection journal about one issued signature
+type SignedEntry struct {
+	Kind       string `json:"kind"`
+	EpochIndex int    `json:"epochIndex"`
+	Creator    string `json:"creator"`
+	Index      int    `json:"index"`
+	Hash       string `json:"hash"`
+}
+
+type SlashingProtectionMetadata struct {
+	InterchangeFormatVersion int    `json:"interchangeFormatVersion"`
+	NetworkId                string `json:"networkId"`
+	Pubkey                   string `json:"pubkey"`
+}
+
+// SlashingProtectionInterchange is the file format to move journal of the key between machines
+type SlashingProtectionInterchange struct {
+	Metadata SlashingProtectionMetadata `json:"metadata"`
+	Entries  []SignedEntry              `json:"entries"`
+}
@@ -1,10 +1,8 @@
 package threads
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"strings"
 	"time"
@@ -15,8 +13,6 @@ import (
 	"github.com/modulrcloud/modulr-anchors-core/utils"
 )
 
-var httpClient = &http.Client{Timeout: 5 * time.Second}
-
 func AnchorRotationCollectorThread() {
 
 	ticker := time.NewTicker(5 * time.Second)
@@ -105,9 +101,9 @@ func processCreatorRotation(epochHandler *structures.EpochDataHandler, creator s
 		return true, false
 	}
 
-	signatures := collectRotationSignatures(epochHandler, creator, stat)
-	majority := utils.GetQuorumMajority(epochHandler)
-	if len(signatures) < majority {
+	quorumWeights := utils.GetQuorumWeights(epochHandler)
+	signatures := collectRotationSignatures(epochHandler, creator, stat, quorumWeights)
+	if utils.GetSignaturesWeight(quorumWeights, signatures) < utils.GetQuorumMajorityByWeights(quorumWeights) {
 		return true, false
 	}
 
@@ -121,25 +117,27 @@ func processCreatorRotation(epochHandler *structures.EpochDataHandler, creator s
 		utils.LogWithTime(fmt.Sprintf("anchor rotation: failed to persist proof for %s epoch %d: %v", creator, epochHandler.Id, err), utils.YELLOW_COLOR)
 		return true, false
 	}
-	globals.MEMPOOL.AddAggregatedAnchorRotationProof(proof)
+	if err := globals.MEMPOOL.AddAggregatedAnchorRotationProof(proof); err != nil {
+		utils.LogWithTime(fmt.Sprintf("anchor rotation: failed to add proof for %s epoch %d to mempool: %v", creator, epochHandler.Id, err), utils.RED_COLOR)
+	}
 	broadcastRotationProof(epochHandler, proof)
 	utils.LogWithTime(fmt.Sprintf("anchor rotation: collected %d signatures for %s in epoch %d", len(signatures), creator, epochHandler.Id), utils.GREEN_COLOR)
 	return true, true
 }
 
-func collectRotationSignatures(epochHandler *structures.EpochDataHandler, creator string, stat structures.VotingStat) map[string]string {
+func collectRotationSignatures(epochHandler *structures.EpochDataHandler, creator string, stat structures.VotingStat, quorumWeights map[string]uint64) map[string]string {
 	quorumMembers := utils.GetQuorumUrlsAndPubkeys(epochHandler)
 	payload := structures.AnchorRotationProofRequest{EpochIndex: epochHandler.Id, Creator: creator, Proposal: stat}
 	requestBody, _ := json.Marshal(payload)
 	signatures := make(map[string]string)
-	majority := utils.GetQuorumMajority(epochHandler)
+	majority := utils.GetQuorumMajorityByWeights(quorumWeights)
 
 	for _, member := range quorumMembers {
 		if member.PubKey == globals.CONFIGURATION.PublicKey || member.Url == "" {
 			continue
 		}
 		endpoint := strings.TrimRight(member.Url, "/") + "/request_anchor_rotation_proof"
-		body, status, err := postJSON(endpoint, requestBody)
+		body, status, err := utils.PostJSON(endpoint, requestBody)
 		if err != nil {
 			continue
 		}
@@ -169,31 +167,13 @@ func collectRotationSignatures(epochHandler *structures.EpochDataHandler, creato
 				signatures[member.PubKey] = response.Signature
 			}
 		}
-		if len(signatures) >= majority {
+		if utils.GetSignaturesWeight(quorumWeights, signatures) >= majority {
 			break
 		}
 	}
 	return signatures
 }
 
-func postJSON(url string, payload []byte) ([]byte, int, error) {
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
-	if err != nil {
-		return nil, 0, err
-	}
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return nil, 0, err
-	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, resp.StatusCode, err
-	}
-	return body, resp.StatusCode, nil
-}
-
 func broadcastRotationProof(epochHandler *structures.EpochDataHandler, proof structures.AggregatedAnchorRotationProof) {
 	payload := structures.AcceptAggregatedAnchorRotationProofRequest{AggregatedRotationProofs: []structures.AggregatedAnchorRotationProof{proof}}
 	body, _ := json.Marshal(payload)
@@ -202,7 +182,7 @@ func broadcastRotationProof(epochHandler *structures.EpochDataHandler, proof str
 			continue
 		}
 		endpoint := strings.TrimRight(member.Url, "/") + "/accept_aggregated_anchor_rotation_proof"
-		if _, _, err := postJSON(endpoint, body); err != nil {
+		if _, _, err := utils.PostJSON(endpoint, body); err != nil {
 			utils.LogWithTime(fmt.Sprintf("anchor rotation: failed to broadcast proof to %s: %v", member.PubKey, err), utils.YELLOW_COLOR)
 		}
 	}
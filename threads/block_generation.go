@@ -2,6 +2,7 @@ package threads
 
 import (
 	"encoding/json"
+	"errors"
 	"strconv"
 	"time"
 
@@ -11,8 +12,6 @@ import (
 	"github.com/modulrcloud/modulr-anchors-core/handlers"
 	"github.com/modulrcloud/modulr-anchors-core/structures"
 	"github.com/modulrcloud/modulr-anchors-core/utils"
-
-	"github.com/syndtr/goleveldb/leveldb"
 )
 
 func BlocksGenerationThread() {
@@ -21,17 +20,17 @@ func BlocksGenerationThread() {
 
 		handlers.APPROVEMENT_THREAD_METADATA.RWMutex.RLock()
 
-		blockTime := handlers.APPROVEMENT_THREAD_METADATA.Handler.NetworkParameters.BlockTime
+		networkParams := handlers.APPROVEMENT_THREAD_METADATA.Handler.NetworkParameters.CopyNetworkParameters()
 
 		epochHandlers := handlers.APPROVEMENT_THREAD_METADATA.Handler.GetEpochHandlers()
 
 		handlers.APPROVEMENT_THREAD_METADATA.RWMutex.RUnlock()
 
 		for idx := range epochHandlers {
-			generateBlock(&epochHandlers[idx])
+			generateBlock(&epochHandlers[idx], &networkParams)
 		}
 
-		time.Sleep(time.Duration(blockTime) * time.Millisecond)
+		time.Sleep(time.Duration(networkParams.BlockTime) * time.Millisecond)
 	}
 
 }
@@ -66,7 +65,7 @@ func removeGenerationMetadata(epochFullID string) {
 
 }
 
-func generateBlock(epochHandlerRef *structures.EpochDataHandler) {
+func generateBlock(epochHandlerRef *structures.EpochDataHandler, networkParams *structures.NetworkParameters) {
 
 	if epochHandlerRef == nil {
 		return
@@ -110,15 +109,56 @@ func generateBlock(epochHandlerRef *structures.EpochDataHandler) {
 		restData[key] = value
 	}
 
-	extraData := block_pack.ExtraDataToBlock{
+	candidates := block_pack.ExtraDataToBlock{
 		Rest:                               restData,
-		AggregatedAnchorRotationProofs:     globals.MEMPOOL.DrainAggregatedAnchorRotationProofs(),
-		AggregatedLeaderFinalizationProofs: globals.MEMPOOL.DrainAggregatedLeaderFinalizationProofs(),
+		AggregatedAnchorRotationProofs:     drainPendingAnchorRotationProofs(),
+		AggregatedLeaderFinalizationProofs: drainPendingLeaderFinalizationProofs(epochIndex),
+		AnchorMembershipRequests:           globals.MEMPOOL.DrainAnchorMembershipRequests(epochIndex),
+		AggregatedNetworkParametersProofs:  globals.MEMPOOL.DrainAggregatedNetworkParametersProofs(epochIndex),
+		BlockEquivocationEvidences:         globals.MEMPOOL.DrainBlockEquivocationEvidences(epochIndex),
+		DoubleVoteEvidences:                globals.MEMPOOL.DrainDoubleVoteEvidences(epochIndex),
+		Transactions:                       drainPendingTransactions(epochHandlerRef, networkParams.TxLimitPerBlock),
+	}
+
+	// Take as many items as block limits allow, the rest goes back to mempool for the next blocks
+
+	emptyBlock := block_pack.NewBlock(block_pack.ExtraDataToBlock{Rest: restData}, epochHandlerRef, metadata)
+
+	emptyBlock.SignBlock()
+
+	extraData, leftovers, dropped := block_pack.PackExtraData(candidates, networkParams.TxLimitPerBlock, int(networkParams.MaxBlockSizeInBytes)-emptyBlock.SizeInBytes())
+
+	returnToMempool(leftovers)
+
+	// Items bigger than the empty block allows can't be included ever
+
+	if droppedKeys := dropped.MempoolKeys(); len(droppedKeys) > 0 {
+
+		utils.LogWithTime("Dropped "+strconv.Itoa(len(droppedKeys))+" mempool items which don't fit even to the empty block", utils.YELLOW_COLOR)
+
+		if err := globals.MEMPOOL.Forget(droppedKeys); err != nil {
+			utils.LogWithTime("Failed to forget mempool items: "+err.Error(), utils.RED_COLOR)
+		}
+
 	}
 
-	blockDbAtomicBatch := new(leveldb.Batch)
+	// Items can't be added again while the block with them is not finalized
+
+	globals.MEMPOOL.MarkInFlight(extraData.MempoolKeys())
+
+	blockDbAtomicBatch := new(databases.Batch)
+
+	blockCandidate := block_pack.NewBlock(extraData, epochHandlerRef, metadata)
+
+	if err := blockCandidate.VerifyLimits(networkParams); err != nil {
+
+		utils.LogWithTime("Block candidate exceeds limits: "+err.Error(), utils.RED_COLOR)
 
-	blockCandidate := block_pack.NewBlock(extraData, epochFullID, metadata)
+		returnToMempool(extraData)
+
+		return
+
+	}
 
 	blockHash := blockCandidate.GetHash()
 
@@ -131,27 +171,166 @@ func generateBlock(epochHandlerRef *structures.EpochDataHandler) {
 	blockBytes, serializeErr := json.Marshal(blockCandidate)
 
 	if serializeErr != nil {
+
+		returnToMempool(extraData)
+
 		return
+
 	}
 
+	// Metadata is moved forward only when it's serialized, otherwise the next block would point to the block which wasn't stored
+
 	handlers.GENERATION_THREAD_METADATA.Lock()
 
-	metadata.PrevHash = blockHash
+	nextMetadata := *metadata
+
+	handlers.GENERATION_THREAD_METADATA.Unlock()
+
+	nextMetadata.PrevHash = blockHash
+
+	nextMetadata.NextIndex++
 
-	metadata.NextIndex++
+	gtBytes, err := json.Marshal(nextMetadata)
+
+	if err != nil {
+
+		utils.LogWithTime("Failed to marshal generation metadata: "+err.Error(), utils.RED_COLOR)
+
+		returnToMempool(extraData)
+
+		return
+
+	}
+
+	handlers.GENERATION_THREAD_METADATA.Lock()
+
+	metadata.PrevHash = nextMetadata.PrevHash
+
+	metadata.NextIndex = nextMetadata.NextIndex
 
 	handlers.GENERATION_THREAD_METADATA.Unlock()
 
-	if gtBytes, err := json.Marshal(metadata); err == nil {
+	blockDbAtomicBatch.Put([]byte(blockID), blockBytes)
+
+	blockDbAtomicBatch.Put([]byte("GT:"+epochFullID), gtBytes)
+
+	// Items of extra data stay in persistent mempool until the block is finalized
+
+	blockDbAtomicBatch.Put([]byte(IN_FLIGHT_KEY_PREFIX+blockID), []byte("TRUE"))
+
+	if err := databases.BLOCKS.Write(blockDbAtomicBatch); err != nil {
+		panic("Can't store GT and block candidate")
+	}
+
+}
+
+func returnToMempool(extraData block_pack.ExtraDataToBlock) {
+
+	globals.MEMPOOL.ReleaseInFlight(extraData.MempoolKeys())
+
+	// Items stay in persistent mempool until their block is finalized, so item which wasn't added back is restored on restart
+
+	var errs []error
+
+	for _, proof := range extraData.AggregatedAnchorRotationProofs {
+		errs = append(errs, globals.MEMPOOL.AddAggregatedAnchorRotationProof(proof))
+	}
+
+	for _, proof := range extraData.AggregatedLeaderFinalizationProofs {
+		errs = append(errs, globals.MEMPOOL.AddAggregatedLeaderFinalizationProof(proof))
+	}
+
+	for _, request := range extraData.AnchorMembershipRequests {
+		errs = append(errs, globals.MEMPOOL.AddAnchorMembershipRequest(request))
+	}
+
+	for _, proof := range extraData.AggregatedNetworkParametersProofs {
+		errs = append(errs, globals.MEMPOOL.AddAggregatedNetworkParametersProof(proof))
+	}
+
+	for _, evidence := range extraData.BlockEquivocationEvidences {
+		errs = append(errs, globals.MEMPOOL.AddBlockEquivocationEvidence(evidence))
+	}
+
+	for _, evidence := range extraData.DoubleVoteEvidences {
+		errs = append(errs, globals.MEMPOOL.AddDoubleVoteEvidence(evidence))
+	}
+
+	errs = append(errs, globals.MEMPOOL.ReturnTransactions(extraData.Transactions))
 
-		blockDbAtomicBatch.Put([]byte(blockID), blockBytes)
+	if err := errors.Join(errs...); err != nil {
+		utils.LogWithTime("Failed to return items to mempool: "+err.Error(), utils.RED_COLOR)
+	}
+
+}
 
-		blockDbAtomicBatch.Put([]byte("GT:"+epochFullID), gtBytes)
+// drainPendingTransactions takes transactions from mempool and drops the ones which became invalid
+// (e.g. nonce was already finalized) or belong to another creator - voters accept transactions only in blocks of their creator
+func drainPendingTransactions(epochHandler *structures.EpochDataHandler, limit int) []structures.AnchorTransaction {
 
-		if err := databases.BLOCKS.Write(blockDbAtomicBatch, nil); err != nil {
-			panic("Can't store GT and block candidate")
+	var transactions []structures.AnchorTransaction
+
+	var invalidKeys []string
+
+	for _, tx := range globals.MEMPOOL.DrainTransactions(limit) {
+		if tx.Creator == globals.CONFIGURATION.PublicKey && utils.VerifyAnchorTransaction(&tx, epochHandler) == nil {
+			transactions = append(transactions, tx)
+		} else {
+			invalidKeys = append(invalidKeys, globals.AnchorTransactionMempoolKey(tx))
 		}
+	}
 
+	if err := globals.MEMPOOL.Forget(invalidKeys); err != nil {
+		utils.LogWithTime("Failed to forget mempool items: "+err.Error(), utils.RED_COLOR)
 	}
 
+	return transactions
+
+}
+
+// drainPendingAnchorRotationProofs takes AARPs from mempool and forgets the ones already included to our finalized blocks
+func drainPendingAnchorRotationProofs() []structures.AggregatedAnchorRotationProof {
+
+	var proofs []structures.AggregatedAnchorRotationProof
+
+	var includedKeys []string
+
+	for _, proof := range globals.MEMPOOL.DrainAggregatedAnchorRotationProofs() {
+		if utils.IsAggregatedAnchorRotationProofIncluded(&proof) {
+			includedKeys = append(includedKeys, globals.AggregatedAnchorRotationProofMempoolKey(proof))
+		} else {
+			proofs = append(proofs, proof)
+		}
+	}
+
+	if err := globals.MEMPOOL.Forget(includedKeys); err != nil {
+		utils.LogWithTime("Failed to forget mempool items: "+err.Error(), utils.RED_COLOR)
+	}
+
+	return proofs
+
+}
+
+// drainPendingLeaderFinalizationProofs takes ALFPs of the block epoch and earlier ones from mempool (voters reject blocks with ALFPs
+// of later epochs) and forgets the ones already included to our finalized blocks or related to epochs which are not supported anymore
+func drainPendingLeaderFinalizationProofs(epochIndex int) []structures.AggregatedLeaderFinalizationProof {
+
+	var proofs []structures.AggregatedLeaderFinalizationProof
+
+	var outdatedKeys []string
+
+	for _, proof := range globals.MEMPOOL.DrainAggregatedLeaderFinalizationProofs(epochIndex) {
+		if utils.IsAggregatedLeaderFinalizationProofIncluded(&proof) || utils.GetEpochHandlerByID(proof.EpochIndex) == nil {
+			outdatedKeys = append(outdatedKeys, globals.AggregatedLeaderFinalizationProofMempoolKey(proof))
+		} else {
+			proofs = append(proofs, proof)
+		}
+	}
+
+	if err := globals.MEMPOOL.Forget(outdatedKeys); err != nil {
+		utils.LogWithTime("Failed to forget mempool items: "+err.Error(), utils.RED_COLOR)
+	}
+
+	return proofs
+
 }
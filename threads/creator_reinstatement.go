@@ -0,0 +1,162 @@
+package threads
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/modulrcloud/modulr-anchors-core/cryptography"
+	"github.com/modulrcloud/modulr-anchors-core/globals"
+	"github.com/modulrcloud/modulr-anchors-core/handlers"
+	"github.com/modulrcloud/modulr-anchors-core/structures"
+	"github.com/modulrcloud/modulr-anchors-core/utils"
+)
+
+// CreatorReinstatementThread watches progress of our own blocks. When the approved height stops growing, some anchors
+// might have disabled proofs for us, so we present the last AFP to quorum to get reinstated
+func CreatorReinstatementThread() {
+
+	lastSeenAfps := make(map[int]string) // epochIndex => blockId of the last AFP we saw on previous iteration
+
+	lastPresentedAfps := make(map[int]string) // epochIndex => blockId of the last AFP used for reinstatement
+
+	for {
+
+		time.Sleep(time.Duration(getHealthCheckIntervalMs()) * time.Millisecond)
+
+		handlers.APPROVEMENT_THREAD_METADATA.RWMutex.RLock()
+		epochHandlers := handlers.APPROVEMENT_THREAD_METADATA.Handler.GetEpochHandlers()
+		handlers.APPROVEMENT_THREAD_METADATA.RWMutex.RUnlock()
+
+		for idx := range epochHandlers {
+
+			epochHandler := &epochHandlers[idx]
+
+			if !slices.Contains(epochHandler.AnchorsRegistry, globals.CONFIGURATION.PublicKey) || utils.HasAggregatedAnchorRotationProof(epochHandler.Id, globals.CONFIGURATION.PublicKey) {
+				continue
+			}
+
+			afp, ok := getOwnLastAfp(epochHandler.Id)
+
+			if !ok {
+				continue
+			}
+
+			stalled := lastSeenAfps[epochHandler.Id] == afp.BlockId
+
+			lastSeenAfps[epochHandler.Id] = afp.BlockId
+
+			if !stalled || lastPresentedAfps[epochHandler.Id] == afp.BlockId {
+				continue
+			}
+
+			if requestReinstatement(epochHandler, afp) {
+				lastPresentedAfps[epochHandler.Id] = afp.BlockId
+			}
+
+		}
+
+		for epochIndex := range lastSeenAfps {
+			if utils.GetEpochHandlerByID(epochIndex) == nil {
+				delete(lastSeenAfps, epochIndex)
+				delete(lastPresentedAfps, epochIndex)
+			}
+		}
+
+	}
+
+}
+
+func getOwnLastAfp(epochIndex int) (structures.AggregatedFinalizationProof, bool) {
+
+	finalizationRuntimes.RLock()
+	runtime, ok := finalizationRuntimes.Data[epochIndex]
+	finalizationRuntimes.RUnlock()
+
+	if !ok {
+		return structures.AggregatedFinalizationProof{}, false
+	}
+
+	runtime.Lock()
+	defer runtime.Unlock()
+
+	if runtime.Grabber.AcceptedIndex < 0 || runtime.Grabber.AfpForPrevious.BlockId == "" {
+		return structures.AggregatedFinalizationProof{}, false
+	}
+
+	return runtime.Grabber.AfpForPrevious, true
+
+}
+
+func requestReinstatement(epochHandler *structures.EpochDataHandler, afp structures.AggregatedFinalizationProof) bool {
+
+	req := structures.CreatorReinstatementRequest{EpochIndex: epochHandler.Id, Creator: globals.CONFIGURATION.PublicKey, Afp: afp}
+	requestBody, _ := json.Marshal(req)
+	quorumWeights := utils.GetQuorumWeights(epochHandler)
+	majority := utils.GetQuorumMajorityByWeights(quorumWeights)
+	epochFullID := epochHandler.Hash + "#" + strconv.Itoa(epochHandler.Id)
+	dataThatShouldBeSigned := utils.GetCreatorReinstatementSigningData(epochHandler.Id, req.Creator, &afp, epochFullID)
+	signatures := make(map[string]string)
+
+	if slices.Contains(epochHandler.Quorum, globals.CONFIGURATION.PublicKey) {
+		if response := utils.ProcessCreatorReinstatementRequest(&req, epochHandler); response.Status == "OK" {
+			signatures[globals.CONFIGURATION.PublicKey] = response.Signature
+		}
+	}
+
+	for _, member := range utils.GetQuorumUrlsAndPubkeys(epochHandler) {
+		if utils.GetSignaturesWeight(quorumWeights, signatures) >= majority {
+			break
+		}
+		if member.PubKey == globals.CONFIGURATION.PublicKey || member.Url == "" {
+			continue
+		}
+		body, _, err := utils.PostJSON(strings.TrimRight(member.Url, "/")+"/request_creator_reinstatement", requestBody)
+		if err != nil {
+			continue
+		}
+		var response structures.CreatorReinstatementResponse
+		if err := json.Unmarshal(body, &response); err != nil || response.Status != "OK" {
+			continue
+		}
+		if cryptography.VerifySignature(dataThatShouldBeSigned, member.PubKey, response.Signature) {
+			signatures[member.PubKey] = response.Signature
+		}
+	}
+
+	if utils.GetSignaturesWeight(quorumWeights, signatures) < majority {
+		return false
+	}
+
+	proof := structures.AggregatedCreatorReinstatementProof{EpochIndex: epochHandler.Id, Creator: req.Creator, Afp: afp, Signatures: signatures}
+
+	if err := utils.ApplyAggregatedCreatorReinstatementProof(&proof, epochHandler); err != nil {
+		utils.LogWithTime(fmt.Sprintf("reinstatement: failed to apply proof in epoch %d: %v", epochHandler.Id, err), utils.YELLOW_COLOR)
+		return false
+	}
+
+	// Flag should be cleared on every anchor, not only on quorum members
+
+	proofBody, _ := json.Marshal(proof)
+
+	for _, anchor := range epochHandler.AnchorsRegistry {
+		if anchor == globals.CONFIGURATION.PublicKey {
+			continue
+		}
+		anchorStorage := utils.GetAnchorFromApprovementThreadState(anchor)
+		if anchorStorage == nil || anchorStorage.AnchorUrl == "" {
+			continue
+		}
+		if _, _, err := utils.PostJSON(strings.TrimRight(anchorStorage.AnchorUrl, "/")+"/accept_creator_reinstatement_proof", proofBody); err != nil {
+			utils.LogWithTime(fmt.Sprintf("reinstatement: failed to broadcast proof to %s: %v", anchor, err), utils.YELLOW_COLOR)
+		}
+	}
+
+	utils.LogWithTime(fmt.Sprintf("reinstatement: collected %d signatures in epoch %d", len(signatures), epochHandler.Id), utils.GREEN_COLOR)
+
+	return true
+
+}
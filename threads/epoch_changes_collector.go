@@ -0,0 +1,54 @@
+package threads
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/modulrcloud/modulr-anchors-core/block_pack"
+	"github.com/modulrcloud/modulr-anchors-core/handlers"
+	"github.com/modulrcloud/modulr-anchors-core/utils"
+)
+
+// EpochChangesCollectorThread collects membership requests and network parameters proposals of supported epochs whose time
+// is over and stores them for rotation. Epoch finish proof and blocks which node doesn't have are fetched from other anchors here,
+// so rotation itself never waits for network
+func EpochChangesCollectorThread() {
+
+	ticker := time.NewTicker(2 * time.Second)
+
+	defer ticker.Stop()
+
+	for range ticker.C {
+
+		handlers.APPROVEMENT_THREAD_METADATA.RWMutex.RLock()
+		handlerCopy := handlers.APPROVEMENT_THREAD_METADATA.Handler
+		epochHandlers := handlerCopy.GetEpochHandlers()
+		handlers.APPROVEMENT_THREAD_METADATA.RWMutex.RUnlock()
+
+		for idx := range epochHandlers {
+
+			epochHandler := &epochHandlers[idx]
+
+			if !utils.EpochIsOver(epochHandler, &handlerCopy) {
+				continue
+			}
+
+			if _, err := block_pack.LoadEpochChanges(epochHandler.Id); err == nil {
+				continue
+			}
+
+			changes, err := block_pack.CollectEpochChanges(epochHandler)
+
+			if err != nil {
+				continue
+			}
+
+			if err := block_pack.StoreEpochChanges(epochHandler.Id, changes); err != nil {
+				utils.LogWithTime(fmt.Sprintf("epoch changes: failed to store changes of epoch %d: %v", epochHandler.Id, err), utils.YELLOW_COLOR)
+			}
+
+		}
+
+	}
+
+}
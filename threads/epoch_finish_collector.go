@@ -0,0 +1,160 @@
+package threads
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/modulrcloud/modulr-anchors-core/cryptography"
+	"github.com/modulrcloud/modulr-anchors-core/globals"
+	"github.com/modulrcloud/modulr-anchors-core/handlers"
+	"github.com/modulrcloud/modulr-anchors-core/structures"
+	"github.com/modulrcloud/modulr-anchors-core/utils"
+)
+
+// EpochFinishCollectorThread collects quorum signatures for the summary (last finalized block of each creator)
+// of supported epochs whose time is over. The next epoch is built with the changes of epoch up to this summary
+func EpochFinishCollectorThread() {
+
+	ticker := time.NewTicker(5 * time.Second)
+
+	defer ticker.Stop()
+
+	for range ticker.C {
+
+		handlers.APPROVEMENT_THREAD_METADATA.RWMutex.RLock()
+		handlerCopy := handlers.APPROVEMENT_THREAD_METADATA.Handler
+		epochHandlers := handlerCopy.GetEpochHandlers()
+		handlers.APPROVEMENT_THREAD_METADATA.RWMutex.RUnlock()
+
+		for idx := range epochHandlers {
+
+			epochHandler := &epochHandlers[idx]
+
+			if !utils.EpochIsOver(epochHandler, &handlerCopy) || utils.HasAggregatedEpochFinishProof(epochHandler.Id) {
+				continue
+			}
+
+			collectEpochFinishProof(epochHandler)
+
+		}
+
+	}
+
+}
+
+func collectEpochFinishProof(epochHandler *structures.EpochDataHandler) {
+
+	summary, err := utils.BuildLocalEpochFinishSummary(epochHandler)
+
+	if err != nil {
+		utils.LogWithTime(fmt.Sprintf("epoch finish: failed to build summary for epoch %d: %v", epochHandler.Id, err), utils.YELLOW_COLOR)
+		return
+	}
+
+	quorumWeights := utils.GetQuorumWeights(epochHandler)
+	majority := utils.GetQuorumMajorityByWeights(quorumWeights)
+	epochFullID := epochHandler.Hash + "#" + strconv.Itoa(epochHandler.Id)
+	dataThatShouldBeSigned := utils.GetEpochFinishSigningData(&summary, epochFullID)
+	signatures := make(map[string]string)
+
+	// Our node signs only one summary per epoch, so it votes after peers agreed on the summary without upgrades
+
+	isQuorumMember := slices.Contains(epochHandler.Quorum, globals.CONFIGURATION.PublicKey)
+
+	ownWeight := uint64(0)
+
+	if isQuorumMember {
+		ownWeight = quorumWeights[globals.CONFIGURATION.PublicKey]
+	}
+
+	requestBody, _ := json.Marshal(structures.EpochFinishVoteRequest{Summary: summary})
+
+	for _, member := range utils.GetQuorumUrlsAndPubkeys(epochHandler) {
+		if utils.GetSignaturesWeight(quorumWeights, signatures)+ownWeight >= majority {
+			break
+		}
+		if member.PubKey == globals.CONFIGURATION.PublicKey || member.Url == "" {
+			continue
+		}
+		endpoint := strings.TrimRight(member.Url, "/") + "/request_epoch_finish_vote"
+		body, _, err := utils.PostJSON(endpoint, requestBody)
+		if err != nil {
+			continue
+		}
+		var response structures.EpochFinishVoteResponse
+		if err := json.Unmarshal(body, &response); err != nil {
+			continue
+		}
+		switch response.Status {
+		case "UPGRADE":
+			// Store fresher stats and try again with the new summary on the next iteration
+			applyEpochFinishUpgrades(epochHandler, summary, response.VotingStats)
+			return
+		case "OK":
+			if cryptography.VerifySignature(dataThatShouldBeSigned, member.PubKey, response.Signature) {
+				signatures[member.PubKey] = response.Signature
+			}
+		}
+	}
+
+	if isQuorumMember && utils.GetSignaturesWeight(quorumWeights, signatures)+ownWeight >= majority {
+		response := utils.ProcessEpochFinishVote(&summary, epochHandler)
+		if response.Status != "OK" {
+			return
+		}
+		signatures[globals.CONFIGURATION.PublicKey] = response.Signature
+	}
+
+	proof := structures.AggregatedEpochFinishProof{Summary: summary, Signatures: signatures}
+
+	if utils.VerifyAggregatedEpochFinishProof(&proof, epochHandler) != nil {
+		return
+	}
+
+	if err := utils.StoreAggregatedEpochFinishProof(proof); err != nil {
+		utils.LogWithTime(fmt.Sprintf("epoch finish: failed to persist proof for epoch %d: %v", epochHandler.Id, err), utils.YELLOW_COLOR)
+		return
+	}
+
+	broadcastEpochFinishProof(epochHandler, proof)
+
+	utils.LogWithTime(fmt.Sprintf("epoch finish: collected %d signatures for epoch %d", len(signatures), epochHandler.Id), utils.GREEN_COLOR)
+}
+
+func applyEpochFinishUpgrades(epochHandler *structures.EpochDataHandler, summary structures.EpochFinishSummary, upgrades map[string]structures.VotingStat) {
+	for creator, stat := range upgrades {
+		currentStat, ok := summary.VotingStats[creator]
+		if !ok || stat.Index <= currentStat.Index {
+			continue
+		}
+		if err := utils.VerifyEpochFinishVotingStat(epochHandler, creator, &stat); err != nil {
+			continue
+		}
+		mutex := globals.BLOCK_CREATORS_MUTEX_REGISTRY.GetMutex(epochHandler.Id, creator)
+		mutex.Lock()
+		localStat, err := utils.ReadVotingStat(epochHandler.Id, creator)
+		if err == nil && stat.Index > localStat.Index {
+			if err := utils.StoreVotingStat(epochHandler.Id, creator, stat); err != nil {
+				utils.LogWithTime(fmt.Sprintf("epoch finish: failed to store upgraded stat for %s epoch %d: %v", creator, epochHandler.Id, err), utils.YELLOW_COLOR)
+			}
+		}
+		mutex.Unlock()
+	}
+}
+
+func broadcastEpochFinishProof(epochHandler *structures.EpochDataHandler, proof structures.AggregatedEpochFinishProof) {
+	body, _ := json.Marshal(proof)
+	for _, member := range utils.GetQuorumUrlsAndPubkeys(epochHandler) {
+		if member.PubKey == globals.CONFIGURATION.PublicKey || member.Url == "" {
+			continue
+		}
+		endpoint := strings.TrimRight(member.Url, "/") + "/accept_aggregated_epoch_finish_proof"
+		if _, _, err := utils.PostJSON(endpoint, body); err != nil {
+			utils.LogWithTime(fmt.Sprintf("epoch finish: failed to broadcast proof to %s: %v", member.PubKey, err), utils.YELLOW_COLOR)
+		}
+	}
+}
@@ -2,16 +2,16 @@ package threads
 
 import (
 	"encoding/json"
+	"slices"
 	"strconv"
 	"time"
 
+	"github.com/modulrcloud/modulr-anchors-core/block_pack"
 	"github.com/modulrcloud/modulr-anchors-core/databases"
 	"github.com/modulrcloud/modulr-anchors-core/globals"
 	"github.com/modulrcloud/modulr-anchors-core/handlers"
 	"github.com/modulrcloud/modulr-anchors-core/structures"
 	"github.com/modulrcloud/modulr-anchors-core/utils"
-
-	"github.com/syndtr/goleveldb/leveldb"
 )
 
 func EpochRotationThread() {
@@ -38,6 +38,20 @@ func EpochRotationThread() {
 			continue
 		}
 
+		// Changes of the current epoch are applied to the next one. They are collected by EpochChangesCollectorThread
+
+		changes, ready := block_pack.EpochChangesForRotation(&currentEpoch, &networkParams)
+
+		if !ready {
+			utils.LogWithTime("Epoch rotation waits for changes of epoch "+strconv.Itoa(currentEpoch.Id), utils.YELLOW_COLOR)
+			time.Sleep(2 * time.Second)
+			continue
+		}
+
+		if _, err := block_pack.LoadEpochChanges(currentEpoch.Id); err != nil {
+			utils.LogWithTime("Changes of epoch "+strconv.Itoa(currentEpoch.Id)+" were not collected in time, next epoch is built without them", utils.RED_COLOR)
+		}
+
 		globals.FLOOD_PREVENTION_FLAG_FOR_ROUTES.Store(false)
 
 		handlers.APPROVEMENT_THREAD_METADATA.RWMutex.Lock()
@@ -60,7 +74,7 @@ func EpochRotationThread() {
 
 		epochHandlerRef := handlerRef.SupportedEpochs[latestIndex]
 
-		if utils.EpochStillFresh(&epochHandlerRef, &handlerRef.NetworkParameters) {
+		if epochHandlerRef.Id != currentEpoch.Id || utils.EpochStillFresh(&epochHandlerRef, &handlerRef.NetworkParameters) {
 
 			handlers.APPROVEMENT_THREAD_METADATA.RWMutex.Unlock()
 
@@ -86,7 +100,7 @@ func EpochRotationThread() {
 
 		}
 
-		if err := databases.EPOCH_DATA.Put(keyBytes, valBytes, nil); err != nil {
+		if err := databases.EPOCH_DATA.Put(keyBytes, valBytes); err != nil {
 
 			handlers.APPROVEMENT_THREAD_METADATA.RWMutex.Unlock()
 
@@ -96,25 +110,29 @@ func EpochRotationThread() {
 
 		}
 
-		atomicBatch := new(leveldb.Batch)
+		atomicBatch := new(databases.Batch)
+
+		nextEpochHandler, nextEpochParams, joinedAnchors := block_pack.BuildNextEpochHandler(&epochHandlerRef, handlerRef.NetworkParameters, changes, nil)
 
-		nextEpochId := epochHandlerRef.Id + 1
+		nextEpochId := nextEpochHandler.Id
 
-		nextEpochHash := utils.Blake3(epochHandlerRef.Hash)
+		nextEpochHash := nextEpochHandler.Hash
 
-		nextEpochQuorumSize := handlerRef.NetworkParameters.QuorumSize
+		storeJoinedAnchors(joinedAnchors, atomicBatch)
 
-		nextEpochHandler := structures.EpochDataHandler{
-			Id:              nextEpochId,
-			Hash:            nextEpochHash,
-			AnchorsRegistry: epochHandlerRef.AnchorsRegistry,
-			Quorum:          utils.GetCurrentEpochQuorum(&epochHandlerRef, nextEpochQuorumSize, nextEpochHash),
-			StartTimestamp:  epochHandlerRef.StartTimestamp + uint64(handlerRef.NetworkParameters.EpochDuration),
+		logRegistryChanges(epochHandlerRef.AnchorsRegistry, nextEpochHandler.AnchorsRegistry, nextEpochId)
+
+		// Apply network parameters approved by quorum during the previous epoch
+
+		handlerRef.NetworkParameters = nextEpochParams
+
+		if proposal := changes.NetworkParameters; proposal != nil {
+			utils.LogWithTime("Network parameters proposed by "+proposal.Proposer+" are applied from epoch "+strconv.Itoa(nextEpochId), utils.CYAN_COLOR)
 		}
 
 		handlerRef.SupportedEpochs = append(handlerRef.SupportedEpochs, nextEpochHandler)
 
-		if len(handlerRef.SupportedEpochs) > handlerRef.NetworkParameters.MaxEpochsToSupport {
+		for len(handlerRef.SupportedEpochs) > handlerRef.NetworkParameters.MaxEpochsToSupport {
 
 			dropped := handlerRef.SupportedEpochs[0]
 
@@ -122,17 +140,23 @@ func EpochRotationThread() {
 
 			keyValue := []byte("EPOCH_FINISH:" + strconv.Itoa(dropped.Id))
 
-			if err := databases.FINALIZATION_VOTING_STATS.Put(keyValue, []byte("TRUE"), nil); err != nil {
+			if err := databases.FINALIZATION_VOTING_STATS.Put(keyValue, []byte("TRUE")); err != nil {
 				panic("Failed to mark epoch as finished: " + err.Error())
 			}
 
 			removeFinalizationRuntime(dropped.Id)
 
+			requeueInFlightBlocksOfEpoch(dropped.Id)
+
+			utils.ForgetFinalizationVotes(dropped.Id)
+
+			removeCreatorSnapshots(dropped.Id)
+
 			epochFullID := dropped.Hash + "#" + strconv.Itoa(dropped.Id)
 
 			removeGenerationMetadata(epochFullID)
 
-			if err := databases.BLOCKS.Delete([]byte("GT:"+epochFullID), nil); err != nil {
+			if err := databases.BLOCKS.Delete([]byte("GT:" + epochFullID)); err != nil {
 				utils.LogWithTime("Failed to delete generation metadata: "+err.Error(), utils.RED_COLOR)
 			}
 
@@ -144,7 +168,7 @@ func EpochRotationThread() {
 
 		atomicBatch.Put([]byte("AT"), jsonedHandler)
 
-		if batchCommitErr := databases.APPROVEMENT_THREAD_METADATA.Write(atomicBatch, nil); batchCommitErr != nil {
+		if batchCommitErr := databases.APPROVEMENT_THREAD_METADATA.Write(atomicBatch); batchCommitErr != nil {
 			panic("Error with writing batch to approvement thread db. Try to launch again")
 		}
 
@@ -159,3 +183,36 @@ func EpochRotationThread() {
 	}
 
 }
+
+// storeJoinedAnchors adds storages of anchors which join from the next epoch to the batch
+func storeJoinedAnchors(joinedAnchors map[string]structures.AnchorStorage, atomicBatch *databases.Batch) {
+
+	for pubkey, anchorStorage := range joinedAnchors {
+
+		serializedStorage, err := json.Marshal(anchorStorage)
+
+		if err != nil {
+			panic("Failed to marshal anchor storage: " + err.Error())
+		}
+
+		atomicBatch.Put([]byte(pubkey+"_ANCHOR_STORAGE"), serializedStorage)
+
+	}
+
+}
+
+func logRegistryChanges(registry, nextRegistry []string, nextEpochId int) {
+
+	for _, pubkey := range nextRegistry {
+		if !slices.Contains(registry, pubkey) {
+			utils.LogWithTime("Anchor "+pubkey+" joins the registry from epoch "+strconv.Itoa(nextEpochId), utils.CYAN_COLOR)
+		}
+	}
+
+	for _, pubkey := range registry {
+		if !slices.Contains(nextRegistry, pubkey) {
+			utils.LogWithTime("Anchor "+pubkey+" leaves the registry from epoch "+strconv.Itoa(nextEpochId), utils.CYAN_COLOR)
+		}
+	}
+
+}
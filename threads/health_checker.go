@@ -7,42 +7,102 @@ import (
 	"sync"
 	"time"
 
-	"github.com/modulrcloud/modulr-anchors-core/globals"
 	"github.com/modulrcloud/modulr-anchors-core/handlers"
 	"github.com/modulrcloud/modulr-anchors-core/structures"
 	"github.com/modulrcloud/modulr-anchors-core/utils"
 )
 
-type creatorSnapshot struct {
-	Index int
-	Hash  string
-}
-
+// In-memory copy of snapshots stored in FINALIZATION_VOTING_STATS. Key is epochID:creator
 var creatorSnapshots = struct {
 	sync.Mutex
-	data map[string]creatorSnapshot
-}{data: make(map[string]creatorSnapshot)}
+	data map[string]utils.BlockCreatorHealthSnapshot
+}{data: make(map[string]utils.BlockCreatorHealthSnapshot)}
 
 // HealthCheckerThread monitors block creators for stalled progress.
 func HealthCheckerThread() {
-	intervalMs := globals.GENESIS.NetworkParameters.BlockCreatorsHealthCheckIntervalMs
-	if intervalMs <= 0 {
-		intervalMs = 5000
-	}
+	loadCreatorSnapshots()
+
+	intervalMs := getHealthCheckIntervalMs()
 
 	ticker := time.NewTicker(time.Duration(intervalMs) * time.Millisecond)
 	defer ticker.Stop()
 
 	for range ticker.C {
 		checkCreatorsHealth()
+
+		// Interval might be changed by network parameters governance
+		if newIntervalMs := getHealthCheckIntervalMs(); newIntervalMs != intervalMs {
+			intervalMs = newIntervalMs
+			ticker.Reset(time.Duration(intervalMs) * time.Millisecond)
+		}
+	}
+}
+
+// loadCreatorSnapshots restores snapshots after restart, so stall history is kept and no extra interval is needed for baseline
+func loadCreatorSnapshots() {
+	snapshots, err := utils.LoadHealthSnapshots()
+	if err != nil {
+		utils.LogWithTime(fmt.Sprintf("health checker: failed to load snapshots: %v", err), utils.YELLOW_COLOR)
+		return
+	}
+
+	creatorSnapshots.Lock()
+	defer creatorSnapshots.Unlock()
+
+	for epochID, snapshotsOfEpoch := range snapshots {
+		// Epoch might be dropped right before the restart
+		if utils.GetEpochHandlerByID(epochID) == nil {
+			if err := utils.DeleteHealthSnapshotsOfEpoch(epochID); err != nil {
+				utils.LogWithTime(fmt.Sprintf("health checker: failed to delete snapshots of epoch %d: %v", epochID, err), utils.YELLOW_COLOR)
+			}
+			continue
+		}
+		for creator, snapshot := range snapshotsOfEpoch {
+			creatorSnapshots.data[snapshotKey(epochID, creator)] = snapshot
+		}
+	}
+}
+
+// removeCreatorSnapshots drops snapshots of epoch which is no longer supported
+func removeCreatorSnapshots(epochID int) {
+	prefix := strconv.Itoa(epochID) + ":"
+
+	creatorSnapshots.Lock()
+	for key := range creatorSnapshots.data {
+		if strings.HasPrefix(key, prefix) {
+			delete(creatorSnapshots.data, key)
+		}
+	}
+	creatorSnapshots.Unlock()
+
+	if err := utils.DeleteHealthSnapshotsOfEpoch(epochID); err != nil {
+		utils.LogWithTime(fmt.Sprintf("health checker: failed to delete snapshots of epoch %d: %v", epochID, err), utils.YELLOW_COLOR)
 	}
 }
 
+func getHealthCheckIntervalMs() int64 {
+	handlers.APPROVEMENT_THREAD_METADATA.RWMutex.RLock()
+	intervalMs := handlers.APPROVEMENT_THREAD_METADATA.Handler.NetworkParameters.BlockCreatorsHealthCheckIntervalMs
+	handlers.APPROVEMENT_THREAD_METADATA.RWMutex.RUnlock()
+	if intervalMs <= 0 {
+		intervalMs = 5000
+	}
+	return intervalMs
+}
+
+func getStallDetectionPolicy() structures.StallDetectionPolicy {
+	handlers.APPROVEMENT_THREAD_METADATA.RWMutex.RLock()
+	defer handlers.APPROVEMENT_THREAD_METADATA.RWMutex.RUnlock()
+	return handlers.APPROVEMENT_THREAD_METADATA.Handler.NetworkParameters.StallDetectionPolicy
+}
+
 func checkCreatorsHealth() {
 	handlers.APPROVEMENT_THREAD_METADATA.RWMutex.RLock()
 	epochHandlers := handlers.APPROVEMENT_THREAD_METADATA.Handler.GetEpochHandlers()
 	handlers.APPROVEMENT_THREAD_METADATA.RWMutex.RUnlock()
 
+	policy := getStallDetectionPolicy()
+
 	totalEpochs := len(epochHandlers)
 	totalCreators := 0
 	activeCreators := 0
@@ -54,12 +114,19 @@ func checkCreatorsHealth() {
 		}
 
 		totalCreators += len(epochHandler.AnchorsRegistry)
+
+		// Give creators time to start producing blocks in the new epoch
+		inGracePeriod := uint64(utils.GetUTCTimestampInMilliSeconds()) < epochHandler.StartTimestamp+uint64(policy.EpochStartGracePeriodMs)
+
+		activeInEpoch := 0
+		stalledInEpoch := []string{}
+
 		for _, creator := range epochHandler.AnchorsRegistry {
 			if utils.IsFinalizationProofsDisabled(epochHandler.Id, creator) {
 				continue
 			}
 
-			activeCreators++
+			activeInEpoch++
 			votingStat, err := utils.ReadVotingStat(epochHandler.Id, creator)
 			if err != nil {
 				utils.LogWithTime(
@@ -69,10 +136,20 @@ func checkCreatorsHealth() {
 				continue
 			}
 
-			if evaluateCreatorProgress(epochHandler.Id, creator, votingStat) {
-				stalledCreators++
+			if inGracePeriod {
+				storeSnapshot(epochHandler.Id, creator, votingStat, 0)
+				continue
+			}
+
+			if evaluateCreatorProgress(epochHandler.Id, creator, votingStat, &policy) {
+				stalledInEpoch = append(stalledInEpoch, creator)
 			}
 		}
+
+		activeCreators += activeInEpoch
+		stalledCreators += len(stalledInEpoch)
+
+		disableStalledCreators(epochHandler.Id, stalledInEpoch, activeInEpoch, &policy)
 	}
 
 	summaryColor := utils.GREEN_COLOR
@@ -88,9 +165,10 @@ func checkCreatorsHealth() {
 	)
 }
 
-func evaluateCreatorProgress(epochID int, creator string, current structures.VotingStat) bool {
+// evaluateCreatorProgress returns true when creator had no progress for the number of consecutive checks required by policy
+func evaluateCreatorProgress(epochID int, creator string, current structures.VotingStat, policy *structures.StallDetectionPolicy) bool {
 	if current.Index < 0 {
-		storeSnapshot(epochID, creator, current)
+		storeSnapshot(epochID, creator, current, 0)
 		return false
 	}
 
@@ -100,38 +178,93 @@ func evaluateCreatorProgress(epochID int, creator string, current structures.Vot
 	previous, hasPrevious := creatorSnapshots.data[key]
 	creatorSnapshots.Unlock()
 
-	if !hasPrevious {
-		storeSnapshot(epochID, creator, current)
+	if !hasPrevious || previous.Index != current.Index || previous.Hash != current.Hash {
+		storeSnapshot(epochID, creator, current, 0)
 		return false
 	}
 
-	if previous.Index == current.Index && previous.Hash == current.Hash {
-		if err := utils.DisableFinalizationProofsForCreator(epochID, creator); err != nil {
+	stalledObservations := previous.StalledObservations + 1
+
+	storeSnapshot(epochID, creator, current, stalledObservations)
+
+	return stalledObservations >= policy.GetStalledObservationsToDisable()
+}
+
+func disableStalledCreators(epochID int, stalled []string, active int, policy *structures.StallDetectionPolicy) {
+	if len(stalled) == 0 {
+		return
+	}
+
+	// When (almost) everyone looks stalled at once, it's more likely that our node lags behind the network
+	if policy.LaggingNodeThresholdPercent > 0 && active > 1 && len(stalled)*100 >= active*policy.LaggingNodeThresholdPercent {
+		utils.LogWithTime(
+			fmt.Sprintf("health checker: %d of %d creators look stalled in epoch %d - our node seems to lag, nobody is disabled", len(stalled), active, epochID),
+			utils.YELLOW_COLOR,
+		)
+		return
+	}
+
+	for _, creator := range stalled {
+		if policy.DryRun {
 			utils.LogWithTime(
-				fmt.Sprintf("health checker: failed to disable proofs for %s in epoch %d: %v", creator, epochID, err),
-				utils.RED_COLOR,
+				fmt.Sprintf("health checker: [dry run] would disable proofs for %s in epoch %d", creator, epochID),
+				utils.YELLOW_COLOR,
 			)
-		} else {
+			continue
+		}
+
+		creatorSnapshots.Lock()
+		snapshot := creatorSnapshots.data[snapshotKey(epochID, creator)]
+		creatorSnapshots.Unlock()
+
+		if err := utils.DisableFinalizationProofsForCreator(epochID, creator, snapshot.Index); err != nil {
 			utils.LogWithTime(
-				fmt.Sprintf("health checker: disabled proofs for %s in epoch %d", creator, epochID),
-				utils.YELLOW_COLOR,
+				fmt.Sprintf("health checker: failed to disable proofs for %s in epoch %d: %v", creator, epochID, err),
+				utils.RED_COLOR,
 			)
+			continue
 		}
+
+		utils.LogWithTime(
+			fmt.Sprintf("health checker: disabled proofs for %s in epoch %d (stalled since %d, %d observations)", creator, epochID, snapshot.FirstStallTimestamp, snapshot.StalledObservations),
+			utils.YELLOW_COLOR,
+		)
+
 		creatorSnapshots.Lock()
-		delete(creatorSnapshots.data, key)
+		delete(creatorSnapshots.data, snapshotKey(epochID, creator))
 		creatorSnapshots.Unlock()
-		return true
-	}
 
-	storeSnapshot(epochID, creator, current)
-	return false
+		if err := utils.DeleteHealthSnapshot(epochID, creator); err != nil {
+			utils.LogWithTime(fmt.Sprintf("health checker: failed to delete snapshot for %s in epoch %d: %v", creator, epochID, err), utils.YELLOW_COLOR)
+		}
+	}
 }
 
-func storeSnapshot(epochID int, creator string, stat structures.VotingStat) {
+func storeSnapshot(epochID int, creator string, stat structures.VotingStat, stalledObservations int) {
 	key := snapshotKey(epochID, creator)
+
 	creatorSnapshots.Lock()
-	creatorSnapshots.data[key] = creatorSnapshot{Index: stat.Index, Hash: stat.Hash}
+	previous, hasPrevious := creatorSnapshots.data[key]
+	snapshot := utils.BlockCreatorHealthSnapshot{Index: stat.Index, Hash: stat.Hash, StalledObservations: stalledObservations}
+	if stalledObservations > 0 {
+		// Keep the moment when creator was seen without progress for the first time
+		if hasPrevious && previous.FirstStallTimestamp != 0 {
+			snapshot.FirstStallTimestamp = previous.FirstStallTimestamp
+		} else {
+			snapshot.FirstStallTimestamp = uint64(utils.GetUTCTimestampInMilliSeconds())
+		}
+	}
+	changed := !hasPrevious || previous != snapshot
+	creatorSnapshots.data[key] = snapshot
 	creatorSnapshots.Unlock()
+
+	if !changed {
+		return
+	}
+
+	if err := utils.StoreHealthSnapshot(epochID, creator, snapshot); err != nil {
+		utils.LogWithTime(fmt.Sprintf("health checker: failed to store snapshot for %s in epoch %d: %v", creator, epochID, err), utils.YELLOW_COLOR)
+	}
 }
 
 func snapshotKey(epochID int, creator string) string {
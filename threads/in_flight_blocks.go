@@ -0,0 +1,162 @@
+package threads
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/modulrcloud/modulr-anchors-core/block_pack"
+	"github.com/modulrcloud/modulr-anchors-core/databases"
+	"github.com/modulrcloud/modulr-anchors-core/globals"
+	"github.com/modulrcloud/modulr-anchors-core/handlers"
+	"github.com/modulrcloud/modulr-anchors-core/utils"
+)
+
+// Mempool items included to our block are in-flight until the block gets AFP. Marker IN_FLIGHT:<blockId>
+// is stored atomically with the block, items stay in persistent mempool until the block is finalized.
+// If the block can't be finalized anymore (its epoch is dropped) - items are returned to mempool
+
+const IN_FLIGHT_KEY_PREFIX = "IN_FLIGHT:"
+
+// RestoreMempool loads persisted mempool and sorts out the blocks which were in-flight before restart
+func RestoreMempool() error {
+
+	if err := globals.MEMPOOL.LoadPersisted(); err != nil {
+		return err
+	}
+
+	supportedEpochs := make(map[int]bool)
+
+	for _, epochHandler := range handlers.APPROVEMENT_THREAD_METADATA.Handler.GetEpochHandlers() {
+		supportedEpochs[epochHandler.Id] = true
+	}
+
+	for _, blockId := range loadInFlightBlockIds(IN_FLIGHT_KEY_PREFIX) {
+
+		block := loadInFlightBlock(blockId)
+
+		epochIndex, _ := strconv.Atoi(strings.Split(blockId, ":")[0])
+
+		switch {
+
+		case block == nil:
+
+			databases.BLOCKS.Delete([]byte(IN_FLIGHT_KEY_PREFIX + blockId))
+
+		case inFlightBlockFinalized(blockId):
+
+			forgetInFlightBlock(blockId, block)
+
+		case supportedEpochs[epochIndex]:
+
+			globals.MEMPOOL.MarkInFlight(block.ExtraData.MempoolKeys())
+
+		default:
+
+			// Items are already pending after load, so only the marker should be removed
+
+			databases.BLOCKS.Delete([]byte(IN_FLIGHT_KEY_PREFIX + blockId))
+
+		}
+
+	}
+
+	return nil
+
+}
+
+// forgetInFlightBlock removes items of finalized block from mempool
+func forgetInFlightBlock(blockId string, block *block_pack.Block) {
+
+	batch := databases.NewAtomicBatch()
+
+	forgetInFlightBlockInBatch(blockId, block, batch)
+
+	if err := batch.Write(); err != nil {
+		utils.LogWithTime("Failed to delete in-flight marker of "+blockId+": "+err.Error(), utils.RED_COLOR)
+		return
+	}
+
+	globals.MEMPOOL.ForgetCommitted(block.ExtraData.MempoolKeys())
+
+}
+
+// forgetInFlightBlockInBatch adds deletion of marker and persisted items of block to batch
+func forgetInFlightBlockInBatch(blockId string, block *block_pack.Block, batch *databases.AtomicBatch) {
+
+	globals.MEMPOOL.ForgetInBatch(block.ExtraData.MempoolKeys(), batch)
+
+	batch.Delete(databases.BLOCKS, []byte(IN_FLIGHT_KEY_PREFIX+blockId))
+
+}
+
+// requeueInFlightBlocksOfEpoch returns items of not finalized blocks of dropped epoch back to mempool
+func requeueInFlightBlocksOfEpoch(epochIndex int) {
+
+	for _, blockId := range loadInFlightBlockIds(IN_FLIGHT_KEY_PREFIX + strconv.Itoa(epochIndex) + ":") {
+
+		block := loadInFlightBlock(blockId)
+
+		if block != nil && inFlightBlockFinalized(blockId) {
+
+			forgetInFlightBlock(blockId, block)
+
+			continue
+
+		}
+
+		if block != nil {
+
+			returnToMempool(block.ExtraData)
+
+			utils.LogWithTime("Items of not finalized block "+blockId+" are returned to mempool", utils.YELLOW_COLOR)
+
+		}
+
+		databases.BLOCKS.Delete([]byte(IN_FLIGHT_KEY_PREFIX + blockId))
+
+	}
+
+}
+
+func loadInFlightBlockIds(prefix string) []string {
+
+	var blockIds []string
+
+	iterator := databases.BLOCKS.NewIterator([]byte(prefix))
+
+	defer iterator.Release()
+
+	for iterator.Next() {
+		blockIds = append(blockIds, strings.TrimPrefix(string(iterator.Key()), IN_FLIGHT_KEY_PREFIX))
+	}
+
+	return blockIds
+
+}
+
+func loadInFlightBlock(blockId string) *block_pack.Block {
+
+	rawBlock, err := databases.BLOCKS.Get([]byte(blockId))
+
+	if err != nil {
+		return nil
+	}
+
+	var block block_pack.Block
+
+	if json.Unmarshal(rawBlock, &block) != nil {
+		return nil
+	}
+
+	return &block
+
+}
+
+func inFlightBlockFinalized(blockId string) bool {
+
+	_, err := databases.EPOCH_DATA.Get([]byte("AFP:" + blockId))
+
+	return err == nil
+
+}
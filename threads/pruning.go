@@ -0,0 +1,88 @@
+package threads
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/modulrcloud/modulr-anchors-core/globals"
+	"github.com/modulrcloud/modulr-anchors-core/handlers"
+	"github.com/modulrcloud/modulr-anchors-core/structures"
+	"github.com/modulrcloud/modulr-anchors-core/utils"
+)
+
+const pruningCheckInterval = time.Minute
+
+// PruningThread deletes data of old epochs according to RETENTION config. Nothing is done in ARCHIVE mode
+func PruningThread() {
+
+	retention := globals.CONFIGURATION.Retention
+
+	switch retention.Mode {
+
+	case "", structures.RETENTION_ARCHIVE:
+		return
+
+	case structures.RETENTION_PRUNE, structures.RETENTION_PRUNE_KEEP_PROOFS:
+
+	default:
+		utils.LogWithTime("Unknown RETENTION.MODE "+retention.Mode+", old epochs won't be pruned", utils.RED_COLOR)
+		return
+
+	}
+
+	keepProofs := retention.Mode == structures.RETENTION_PRUNE_KEEP_PROOFS
+
+	batchSize := retention.BatchSize
+
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+
+	interval := time.Duration(retention.BatchIntervalMs) * time.Millisecond
+
+	if retention.BatchIntervalMs <= 0 {
+		interval = 100 * time.Millisecond
+	}
+
+	for {
+
+		pruneBefore := getPruningBound(retention.KeepEpochs)
+
+		for epochIndex := utils.GetPrunedEpoch() + 1; epochIndex < pruneBefore; epochIndex++ {
+
+			deleted, err := utils.PruneEpoch(epochIndex, keepProofs, batchSize, interval)
+
+			if err != nil {
+				utils.LogWithTime(fmt.Sprintf("Failed to prune epoch %d: %v", epochIndex, err), utils.RED_COLOR)
+				break
+			}
+
+			if deleted > 0 {
+				utils.LogWithTime(fmt.Sprintf("Epoch %d pruned (%s), %d keys deleted", epochIndex, retention.Mode, deleted), utils.CYAN_COLOR)
+			}
+
+		}
+
+		time.Sleep(pruningCheckInterval)
+
+	}
+
+}
+
+// getPruningBound returns the first epoch which should be kept. The epoch dropped last is kept too,
+// because its in-flight blocks might still be returned to mempool
+func getPruningBound(keepEpochs int) int {
+
+	handlers.APPROVEMENT_THREAD_METADATA.RWMutex.RLock()
+
+	defer handlers.APPROVEMENT_THREAD_METADATA.RWMutex.RUnlock()
+
+	bound := handlers.APPROVEMENT_THREAD_METADATA.Handler.GetEpochHandler().Id - max(keepEpochs, 0)
+
+	for _, epochHandler := range handlers.APPROVEMENT_THREAD_METADATA.Handler.GetEpochHandlers() {
+		bound = min(bound, epochHandler.Id-1)
+	}
+
+	return bound
+
+}
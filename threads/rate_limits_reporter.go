@@ -0,0 +1,58 @@
+package threads
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/modulrcloud/modulr-anchors-core/utils"
+)
+
+const rateLimitsReportInterval = time.Minute
+
+// RateLimitsReporterThread periodically logs how many requests, messages and connections were rejected by limits.
+// Nothing is logged while counters don't change
+func RateLimitsReporterThread() {
+
+	ticker := time.NewTicker(rateLimitsReportInterval)
+
+	defer ticker.Stop()
+
+	var lastTotal uint64
+
+	for range ticker.C {
+
+		metrics := utils.RATE_LIMITS_METRICS.Counters()
+
+		counters := []struct {
+			label string
+			value uint64
+		}{
+			{"Http_rate_limited", metrics.HttpRateLimited},
+			{"Http_body_too_large", metrics.HttpBodyTooLarge},
+			{"Http_connections_rejected", metrics.HttpConnectionsRejected},
+			{"Ws_rate_limited", metrics.WsRateLimited},
+			{"Ws_message_too_large", metrics.WsMessageTooLarge},
+			{"Ws_connections_rejected", metrics.WsConnectionsRejected},
+		}
+
+		total := uint64(0)
+
+		summary := make([]string, 0, len(counters))
+
+		for _, counter := range counters {
+			total += counter.value
+			summary = append(summary, utils.ColoredMetric(counter.label, counter.value, utils.CYAN_COLOR, utils.YELLOW_COLOR))
+		}
+
+		if total == lastTotal {
+			continue
+		}
+
+		lastTotal = total
+
+		utils.LogWithTime(fmt.Sprintf("Rate limits: Rejected so far %s", strings.Join(summary, " ")), utils.YELLOW_COLOR)
+
+	}
+
+}
@@ -6,12 +6,10 @@ import (
 	"fmt"
 	"slices"
 	"strconv"
-	"strings"
 	"sync"
 	"time"
 
 	"github.com/modulrcloud/modulr-anchors-core/block_pack"
-	"github.com/modulrcloud/modulr-anchors-core/cryptography"
 	"github.com/modulrcloud/modulr-anchors-core/databases"
 	"github.com/modulrcloud/modulr-anchors-core/globals"
 	"github.com/modulrcloud/modulr-anchors-core/handlers"
@@ -73,10 +71,11 @@ func runFinalizationProofsGrabbing(epochHandler *structures.EpochDataHandler, ru
 	blockIndexToHunt := strconv.Itoa(runtime.Grabber.AcceptedIndex + 1)
 	blockIdForHunting := strconv.Itoa(epochHandler.Id) + ":" + globals.CONFIGURATION.PublicKey + ":" + blockIndexToHunt
 	blockIdThatInPointer := strconv.Itoa(epochHandler.Id) + ":" + globals.CONFIGURATION.PublicKey + ":" + strconv.Itoa(runtime.BlockToShare.Index)
-	majority := utils.GetQuorumMajority(epochHandler)
+	quorumWeights := utils.GetQuorumWeights(epochHandler)
+	majority := utils.GetQuorumMajorityByWeights(quorumWeights)
 	if blockIdForHunting != blockIdThatInPointer {
 
-		blockDataRaw, errDB := databases.BLOCKS.Get([]byte(blockIdForHunting), nil)
+		blockDataRaw, errDB := databases.BLOCKS.Get([]byte(blockIdForHunting))
 		if errDB == nil {
 
 			if parseErr := json.Unmarshal(blockDataRaw, runtime.BlockToShare); parseErr != nil {
@@ -96,7 +95,7 @@ func runFinalizationProofsGrabbing(epochHandler *structures.EpochDataHandler, ru
 	blockHash := runtime.BlockToShare.GetHash()
 	runtime.Grabber.HuntingForBlockId = blockIdForHunting
 	runtime.Grabber.HuntingForBlockHash = blockHash
-	if len(runtime.ProofsCache) < majority {
+	if utils.GetSignaturesWeight(quorumWeights, runtime.ProofsCache) < majority {
 
 		message := websocket_pack.WsFinalizationProofRequest{
 
@@ -112,7 +111,7 @@ func runFinalizationProofsGrabbing(epochHandler *structures.EpochDataHandler, ru
 			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 			defer cancel()
 
-			responses, ok := runtime.Waiter.SendAndWait(ctx, messageJsoned, epochHandler.Quorum, runtime.Connections, majority)
+			responses, ok := runtime.Waiter.SendAndWait(ctx, messageJsoned, epochHandler.Quorum, runtime.Connections, quorumWeights, majority)
 
 			if !ok {
 
@@ -126,19 +125,35 @@ func runFinalizationProofsGrabbing(epochHandler *structures.EpochDataHandler, ru
 
 				if err := json.Unmarshal(raw, &parsedFinalizationProof); err == nil {
 
-					if parsedFinalizationProof.VotedForHash == runtime.Grabber.HuntingForBlockHash {
+					if parsedFinalizationProof.Error != "" {
 
-						dataThatShouldBeSigned := strings.Join(
+						utils.LogWithTime("Block "+runtime.Grabber.HuntingForBlockId+" rejected by "+parsedFinalizationProof.Voter+": "+parsedFinalizationProof.Error, utils.YELLOW_COLOR)
 
-							[]string{runtime.Grabber.AcceptedHash, runtime.Grabber.HuntingForBlockId, runtime.Grabber.HuntingForBlockHash, epochFullId}, ":",
-						)
+						continue
 
-						finalizationProofIsOk := slices.Contains(epochHandler.Quorum, parsedFinalizationProof.Voter) && cryptography.VerifySignature(
+					}
 
-							dataThatShouldBeSigned, parsedFinalizationProof.Voter, parsedFinalizationProof.FinalizationProof,
-						)
+					// Verify proof over the hash voter claims to vote for - proofs for other hashes are indexed to catch double votes
+
+					dataThatShouldBeSigned := utils.GetFinalizationProofSigningData(
+
+						epochHandler.SigningPayloadVersion, runtime.Grabber.AcceptedHash, runtime.Grabber.HuntingForBlockId, parsedFinalizationProof.VotedForHash, epochFullId,
+					)
 
-						if finalizationProofIsOk {
+					finalizationProofIsOk := slices.Contains(epochHandler.Quorum, parsedFinalizationProof.Voter) && utils.VerifyFinalizationProofSignature(
+
+						dataThatShouldBeSigned, parsedFinalizationProof.Voter, parsedFinalizationProof.FinalizationProof,
+					)
+
+					if finalizationProofIsOk {
+
+						utils.RecordFinalizationVote(epochHandler, parsedFinalizationProof.Voter, runtime.Grabber.HuntingForBlockId, structures.FinalizationVote{
+							PrevBlockHash: runtime.Grabber.AcceptedHash,
+							BlockHash:     parsedFinalizationProof.VotedForHash,
+							Signature:     parsedFinalizationProof.FinalizationProof,
+						})
+
+						if parsedFinalizationProof.VotedForHash == runtime.Grabber.HuntingForBlockHash {
 
 							runtime.ProofsCache[parsedFinalizationProof.Voter] = parsedFinalizationProof.FinalizationProof
 
@@ -152,35 +167,49 @@ func runFinalizationProofsGrabbing(epochHandler *structures.EpochDataHandler, ru
 
 		}
 
-		if len(runtime.ProofsCache) >= majority {
+		if utils.GetSignaturesWeight(quorumWeights, runtime.ProofsCache) >= majority {
+
+			aggregatedFinalizationProof, aggregationErr := utils.BuildAggregatedFinalizationProof(
 
-			aggregatedFinalizationProof := structures.AggregatedFinalizationProof{
+				runtime.Grabber.AcceptedHash, blockIdForHunting, blockHash, runtime.ProofsCache, epochHandler,
+			)
 
-				PrevBlockHash: runtime.Grabber.AcceptedHash,
+			if aggregationErr != nil {
 
-				BlockId: blockIdForHunting,
+				utils.LogWithTime("Failed to aggregate finalization proofs for "+blockIdForHunting+": "+aggregationErr.Error(), utils.RED_COLOR)
 
-				BlockHash: blockHash,
+				runtime.ProofsCache = make(map[string]string)
+
+				return
 
-				Proofs: runtime.ProofsCache,
 			}
 
-			keyBytes := []byte("AFP:" + blockIdForHunting)
+			valueBytes, afpMarshalErr := json.Marshal(aggregatedFinalizationProof)
 
-			valueBytes, _ := json.Marshal(aggregatedFinalizationProof)
+			proofGrabberValueBytes, marshalErr := json.Marshal(runtime.Grabber)
 
-			databases.EPOCH_DATA.Put(keyBytes, valueBytes, nil)
+			if afpMarshalErr == nil && marshalErr == nil {
 
-			proofGrabberKeyBytes := []byte(strconv.Itoa(epochHandler.Id) + ":PROOFS_GRABBER")
+				// AFP, proofs grabber state, payload of block and removal of its in-flight items are committed as one batch
 
-			proofGrabberValueBytes, marshalErr := json.Marshal(runtime.Grabber)
+				batch := databases.NewAtomicBatch()
 
-			if marshalErr == nil {
+				batch.Put(databases.EPOCH_DATA, []byte("AFP:"+blockIdForHunting), valueBytes)
 
-				proofsGrabberStoreErr := databases.FINALIZATION_VOTING_STATS.Put(proofGrabberKeyBytes, proofGrabberValueBytes, nil)
+				batch.Put(databases.FINALIZATION_VOTING_STATS, []byte(strconv.Itoa(epochHandler.Id)+":PROOFS_GRABBER"), proofGrabberValueBytes)
+
+				if err := block_pack.ProcessFinalizedBlock(runtime.BlockToShare, epochHandler.Id, batch); err != nil {
+					utils.LogWithTime("Failed to process finalized block "+blockIdForHunting+": "+err.Error(), utils.RED_COLOR)
+				}
+
+				forgetInFlightBlockInBatch(blockIdForHunting, runtime.BlockToShare, batch)
+
+				proofsGrabberStoreErr := batch.Write()
 
 				if proofsGrabberStoreErr == nil {
 
+					globals.MEMPOOL.ForgetCommitted(runtime.BlockToShare.ExtraData.MempoolKeys())
+
 					runtime.Grabber.AfpForPrevious = aggregatedFinalizationProof
 
 					runtime.Grabber.AcceptedIndex++
@@ -211,7 +240,7 @@ func runFinalizationProofsGrabbing(epochHandler *structures.EpochDataHandler, ru
 
 							utils.GREEN_COLOR,
 
-							float64(len(runtime.ProofsCache))/float64(len(epochHandler.Quorum))*100,
+							float64(utils.GetSignaturesWeight(quorumWeights, runtime.ProofsCache))/float64(utils.GetQuorumTotalWeight(quorumWeights))*100,
 						)
 
 						utils.LogWithTime(msg, utils.WHITE_COLOR)
@@ -256,7 +285,7 @@ func ensureFinalizationRuntime(epochHandler *structures.EpochDataHandler) *final
 		Connections:  make(map[string]*websocket.Conn),
 	}
 	grabber := ProofsGrabber{EpochId: epochHandler.Id, AcceptedIndex: -1, AcceptedHash: "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"}
-	if rawGrabber, err := databases.FINALIZATION_VOTING_STATS.Get([]byte(strconv.Itoa(epochHandler.Id)+":PROOFS_GRABBER"), nil); err == nil {
+	if rawGrabber, err := databases.FINALIZATION_VOTING_STATS.Get([]byte(strconv.Itoa(epochHandler.Id) + ":PROOFS_GRABBER")); err == nil {
 		json.Unmarshal(rawGrabber, &grabber)
 	}
 	runtime.Grabber = grabber
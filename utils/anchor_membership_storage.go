@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/modulrcloud/modulr-anchors-core/cryptography"
+	"github.com/modulrcloud/modulr-anchors-core/databases"
+	"github.com/modulrcloud/modulr-anchors-core/globals"
+	"github.com/modulrcloud/modulr-anchors-core/structures"
+)
+
+func anchorMembershipKeyPrefix(epochIndex int) []byte {
+	return []byte("ANCHOR_MEMBERSHIP:" + strconv.Itoa(epochIndex) + ":")
+}
+
+// VerifyAnchorMembershipRequest checks the format of request for the epoch and that it was signed by the anchor itself.
+// Only anchors out of registry can join. Weight can't be set by the anchor itself - it's changed by network parameters proposals
+func VerifyAnchorMembershipRequest(request *structures.AnchorMembershipRequest, epochHandler *structures.EpochDataHandler) error {
+
+	if request.Type != structures.ANCHOR_MEMBERSHIP_JOIN && request.Type != structures.ANCHOR_MEMBERSHIP_LEAVE {
+		return errors.New("unknown membership request type")
+	}
+
+	if request.EpochIndex < 0 || request.Anchor.Pubkey == "" || request.Signature == "" {
+		return errors.New("missing epochIndex, pubkey or signature")
+	}
+
+	if request.EpochIndex != epochHandler.Id {
+		return fmt.Errorf("request epoch %d mismatch", request.EpochIndex)
+	}
+
+	if request.Type == structures.ANCHOR_MEMBERSHIP_JOIN && (request.Anchor.AnchorUrl == "" || request.Anchor.WssAnchorUrl == "") {
+		return errors.New("join request should contain anchor urls")
+	}
+
+	if request.Type == structures.ANCHOR_MEMBERSHIP_JOIN && request.Anchor.Weight != 0 {
+		return errors.New("join request can't set weight, it's changed by network parameters proposals")
+	}
+
+	if request.Type == structures.ANCHOR_MEMBERSHIP_JOIN && slices.Contains(epochHandler.AnchorsRegistry, request.Anchor.Pubkey) {
+		return errors.New("anchor is already in the registry")
+	}
+
+	if request.Type == structures.ANCHOR_MEMBERSHIP_JOIN && globals.GENESIS.UsesBlsFinalizationProofs() {
+
+		if err := VerifyAnchorBlsKey(&request.Anchor); err != nil {
+			return err
+		}
+
+	}
+
+	if !cryptography.VerifySignature(GetAnchorMembershipSigningData(request), request.Anchor.Pubkey, request.Signature) {
+		return errors.New("invalid membership request signature")
+	}
+
+	return nil
+
+}
+
+// StoreFinalizedAnchorMembershipRequests records requests from the block which received AFP (local view of node).
+// Registry is changed by requests from blocks of the epoch finish proof, see block_pack.CollectEpochChanges
+func StoreFinalizedAnchorMembershipRequests(epochIndex int, requests []structures.AnchorMembershipRequest, batch *databases.AtomicBatch) error {
+
+	for _, request := range requests {
+
+		if request.EpochIndex != epochIndex {
+			continue
+		}
+
+		payload, err := json.Marshal(request)
+
+		if err != nil {
+			return err
+		}
+
+		key := append(anchorMembershipKeyPrefix(epochIndex), []byte(request.Anchor.Pubkey)...)
+
+		batch.Put(databases.EPOCH_DATA, key, payload)
+
+	}
+
+	return nil
+
+}
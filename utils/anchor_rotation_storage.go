@@ -7,8 +7,6 @@ import (
 
 	"github.com/modulrcloud/modulr-anchors-core/databases"
 	"github.com/modulrcloud/modulr-anchors-core/structures"
-
-	ldbErrors "github.com/syndtr/goleveldb/leveldb/errors"
 )
 
 func aggregatedAnchorRotationProofKey(epoch int, creator string) []byte {
@@ -20,14 +18,14 @@ func StoreAggregatedAnchorRotationProof(proof structures.AggregatedAnchorRotatio
 	if err != nil {
 		return err
 	}
-	return databases.FINALIZATION_VOTING_STATS.Put(aggregatedAnchorRotationProofKey(proof.EpochIndex, proof.Anchor), payload, nil)
+	return databases.FINALIZATION_VOTING_STATS.Put(aggregatedAnchorRotationProofKey(proof.EpochIndex, proof.Anchor), payload)
 }
 
 func LoadAggregatedAnchorRotationProof(epoch int, creator string) (structures.AggregatedAnchorRotationProof, error) {
 	var proof structures.AggregatedAnchorRotationProof
-	raw, err := databases.FINALIZATION_VOTING_STATS.Get(aggregatedAnchorRotationProofKey(epoch, creator), nil)
+	raw, err := databases.FINALIZATION_VOTING_STATS.Get(aggregatedAnchorRotationProofKey(epoch, creator))
 	if err != nil {
-		if errors.Is(err, ldbErrors.ErrNotFound) {
+		if errors.Is(err, databases.ErrNotFound) {
 			return proof, nil
 		}
 		return proof, err
@@ -42,7 +40,7 @@ func LoadAggregatedAnchorRotationProof(epoch int, creator string) (structures.Ag
 }
 
 func HasAggregatedAnchorRotationProof(epoch int, creator string) bool {
-	if _, err := databases.FINALIZATION_VOTING_STATS.Get(aggregatedAnchorRotationProofKey(epoch, creator), nil); err == nil {
+	if _, err := databases.FINALIZATION_VOTING_STATS.Get(aggregatedAnchorRotationProofKey(epoch, creator)); err == nil {
 		return true
 	}
 	return false
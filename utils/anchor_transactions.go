@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/modulrcloud/modulr-anchors-core/cryptography"
+	"github.com/modulrcloud/modulr-anchors-core/databases"
+	"github.com/modulrcloud/modulr-anchors-core/structures"
+)
+
+// Transaction type is a short identifier, ":" is a separator of signing data fields
+var transactionTypePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+func finalizedTransactionNonceKey(creator string) []byte {
+	return []byte("TX_NONCE:" + creator)
+}
+
+// GetFinalizedTransactionNonce returns the biggest nonce of creator in finalized blocks (0 if there are no transactions yet)
+func GetFinalizedTransactionNonce(creator string) uint64 {
+
+	raw, err := databases.EPOCH_DATA.Get(finalizedTransactionNonceKey(creator))
+
+	if err != nil {
+		return 0
+	}
+
+	nonce, err := strconv.ParseUint(string(raw), 10, 64)
+
+	if err != nil {
+		return 0
+	}
+
+	return nonce
+
+}
+
+// StoreFinalizedTransactionNonces keeps the biggest nonce of each creator. Nonces of the same batch are tracked
+// in memory, because batch isn't visible to reads until it's written
+func StoreFinalizedTransactionNonces(transactions []structures.AnchorTransaction, batch *databases.AtomicBatch) {
+
+	biggestNonces := make(map[string]uint64)
+
+	for _, tx := range transactions {
+
+		if _, ok := biggestNonces[tx.Creator]; !ok {
+			biggestNonces[tx.Creator] = GetFinalizedTransactionNonce(tx.Creator)
+		}
+
+		if tx.Nonce <= biggestNonces[tx.Creator] {
+			continue
+		}
+
+		biggestNonces[tx.Creator] = tx.Nonce
+
+		batch.Put(databases.EPOCH_DATA, finalizedTransactionNonceKey(tx.Creator), []byte(strconv.FormatUint(tx.Nonce, 10)))
+
+	}
+
+}
+
+// VerifyAnchorTransaction checks that transaction is signed by anchor of epoch and its nonce wasn't finalized yet
+func VerifyAnchorTransaction(tx *structures.AnchorTransaction, epochHandler *structures.EpochDataHandler) error {
+
+	if !transactionTypePattern.MatchString(tx.Type) {
+		return errors.New("transaction type should be 1-64 chars of A-Z, a-z, 0-9, _, . and -")
+	}
+
+	if !slices.Contains(epochHandler.AnchorsRegistry, tx.Creator) {
+		return errors.New("creator is not part of epoch")
+	}
+
+	if tx.Nonce <= GetFinalizedTransactionNonce(tx.Creator) {
+		return errors.New("nonce was already used")
+	}
+
+	if !cryptography.VerifySignature(GetAnchorTransactionSigningData(tx), tx.Creator, tx.Signature) {
+		return errors.New("invalid signature")
+	}
+
+	return nil
+
+}
@@ -44,6 +44,13 @@ func bannerLines() []string {
 	lines = append(lines, fmt.Sprintf("■ ws endpoint: %s", endpointLabel(cfg.WebSocketInterface, cfg.WebSocketPort)))
 	lines = append(lines, fmt.Sprintf("■ bootstrap peers: %d", len(cfg.BootstrapNodes)))
 	lines = append(lines, fmt.Sprintf("■ quorum size: %d / block time: %dms", params.QuorumSize, params.BlockTime))
+	if globals.GENESIS.UsesBlsFinalizationProofs() {
+		// BLS key should be registered in genesis or in join request
+		if blsKeys, err := GetBlsKeyPair(); err == nil {
+			lines = append(lines, fmt.Sprintf("■ bls pubkey: %s", blsKeys.Pub))
+			lines = append(lines, fmt.Sprintf("■ bls proof of possession: %s", blsKeys.ProofOfPossession))
+		}
+	}
 	return lines
 }
 
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/modulrcloud/modulr-anchors-core/databases"
+	"github.com/modulrcloud/modulr-anchors-core/structures"
+)
+
+func blockEquivocationKeyPrefix(epochIndex int) []byte {
+	return []byte("BLOCK_EQUIVOCATION:" + strconv.Itoa(epochIndex) + ":")
+}
+
+func blockEquivocationKey(evidence *structures.BlockEquivocationEvidence) []byte {
+	return []byte("BLOCK_EQUIVOCATION:" + evidence.BlockId)
+}
+
+func HasBlockEquivocationEvidence(evidence *structures.BlockEquivocationEvidence) bool {
+	if _, err := databases.EPOCH_DATA.Get(blockEquivocationKey(evidence)); err == nil {
+		return true
+	}
+	return false
+}
+
+// StoreBlockEquivocationEvidence keeps the first evidence for each block id
+func StoreBlockEquivocationEvidence(evidence structures.BlockEquivocationEvidence, batch *databases.AtomicBatch) error {
+
+	if HasBlockEquivocationEvidence(&evidence) {
+		return nil
+	}
+
+	payload, err := json.Marshal(evidence)
+
+	if err != nil {
+		return err
+	}
+
+	batch.Put(databases.EPOCH_DATA, blockEquivocationKey(&evidence), payload)
+
+	return nil
+
+}
+
+func LoadBlockEquivocationEvidences(epochIndex int) ([]structures.BlockEquivocationEvidence, error) {
+
+	iterator := databases.EPOCH_DATA.NewIterator(blockEquivocationKeyPrefix(epochIndex))
+
+	defer iterator.Release()
+
+	evidences := []structures.BlockEquivocationEvidence{}
+
+	for iterator.Next() {
+
+		var evidence structures.BlockEquivocationEvidence
+
+		if err := json.Unmarshal(iterator.Value(), &evidence); err != nil {
+			return nil, err
+		}
+
+		evidences = append(evidences, evidence)
+
+	}
+
+	return evidences, iterator.Error()
+
+}
@@ -0,0 +1,168 @@
+package utils
+
+import (
+	"encoding/hex"
+	"errors"
+	"sync"
+
+	"github.com/modulrcloud/modulr-anchors-core/cryptography"
+	"github.com/modulrcloud/modulr-anchors-core/globals"
+	"github.com/modulrcloud/modulr-anchors-core/structures"
+)
+
+var blsKeyPair = struct {
+	sync.Once
+	box cryptography.BlsBox
+	err error
+}{}
+
+// GetBlsKeyPair returns BLS keypair of our node from BLS_PRIVATE_KEY of configs. The key is never derived from ed25519 one,
+// so leak of one key doesn't reveal the other
+func GetBlsKeyPair() (cryptography.BlsBox, error) {
+
+	blsKeyPair.Do(func() {
+
+		if globals.CONFIGURATION.BlsPrivateKey == "" {
+			blsKeyPair.err = errors.New("BLS_PRIVATE_KEY is required for BLS finalization proofs, generate it with `modulr-anchor bls keygen`")
+			return
+		}
+
+		blsKeyPair.box, blsKeyPair.err = cryptography.BlsKeyPairFromPrivateKey(globals.CONFIGURATION.BlsPrivateKey)
+
+	})
+
+	return blsKeyPair.box, blsKeyPair.err
+
+}
+
+// VerifyAnchorBlsKey checks that anchor has BLS pubkey with valid proof of possession
+func VerifyAnchorBlsKey(anchorStorage *structures.AnchorStorage) error {
+
+	if anchorStorage.BlsPubkey == "" {
+		return errors.New("missing BLS pubkey")
+	}
+
+	if !cryptography.VerifyBlsProofOfPossession(anchorStorage.BlsPubkey, anchorStorage.BlsProofOfPossession) {
+		return errors.New("invalid BLS proof of possession")
+	}
+
+	return nil
+
+}
+
+// GenerateFinalizationProofSignature signs the finalization proof payload with the key required by network format
+func GenerateFinalizationProofSignature(dataToSign string) string {
+
+	if !globals.GENESIS.UsesBlsFinalizationProofs() {
+		return cryptography.GenerateSignature(globals.CONFIGURATION.PrivateKey, dataToSign)
+	}
+
+	blsKeys, err := GetBlsKeyPair()
+
+	if err != nil {
+		return ""
+	}
+
+	return cryptography.GenerateBlsSignature(blsKeys.Prv, dataToSign)
+
+}
+
+// VerifyFinalizationProofSignature verifies the single finalization proof of voter
+func VerifyFinalizationProofSignature(dataThatShouldBeSigned, voter, signature string) bool {
+
+	if !globals.GENESIS.UsesBlsFinalizationProofs() {
+		return cryptography.VerifySignature(dataThatShouldBeSigned, voter, signature)
+	}
+
+	anchorStorage := GetAnchorFromApprovementThreadState(voter)
+
+	if anchorStorage == nil || anchorStorage.BlsPubkey == "" {
+		return false
+	}
+
+	return cryptography.VerifyBlsSignature(dataThatShouldBeSigned, anchorStorage.BlsPubkey, signature)
+
+}
+
+// BuildAggregatedFinalizationProof aggregates verified finalization proofs of quorum members.
+// For BLS networks proofs are merged into single signature with signers bitmap
+func BuildAggregatedFinalizationProof(prevBlockHash, blockId, blockHash string, proofs map[string]string, epochHandler *structures.EpochDataHandler) (structures.AggregatedFinalizationProof, error) {
+
+	aggregatedFinalizationProof := structures.AggregatedFinalizationProof{
+		PrevBlockHash: prevBlockHash,
+		BlockId:       blockId,
+		BlockHash:     blockHash,
+		Proofs:        proofs,
+	}
+
+	if !globals.GENESIS.UsesBlsFinalizationProofs() {
+		return aggregatedFinalizationProof, nil
+	}
+
+	bitmap := make([]byte, (len(epochHandler.Quorum)+7)/8)
+
+	signatures := make([]string, 0, len(proofs))
+
+	for position, member := range epochHandler.Quorum {
+
+		if signature, ok := proofs[member]; ok {
+
+			bitmap[position/8] |= 1 << (position % 8)
+
+			signatures = append(signatures, signature)
+
+		}
+
+	}
+
+	aggregatedSignature, err := cryptography.AggregateBlsSignatures(signatures)
+
+	if err != nil {
+		return aggregatedFinalizationProof, err
+	}
+
+	aggregatedFinalizationProof.Proofs = map[string]string{}
+	aggregatedFinalizationProof.AggregatedSignature = aggregatedSignature
+	aggregatedFinalizationProof.SignersBitmap = hex.EncodeToString(bitmap)
+
+	return aggregatedFinalizationProof, nil
+
+}
+
+func verifyBlsAggregatedFinalizationProof(proof *structures.AggregatedFinalizationProof, dataThatShouldBeSigned string, epochHandler *structures.EpochDataHandler, quorumWeights map[string]uint64) bool {
+
+	bitmap, err := hex.DecodeString(proof.SignersBitmap)
+
+	if err != nil || len(bitmap) != (len(epochHandler.Quorum)+7)/8 {
+		return false
+	}
+
+	accumulatedWeight := uint64(0)
+
+	blsPubkeys := []string{}
+
+	for position, member := range epochHandler.Quorum {
+
+		if bitmap[position/8]&(1<<(position%8)) == 0 {
+			continue
+		}
+
+		anchorStorage := GetAnchorFromApprovementThreadState(member)
+
+		if anchorStorage == nil || anchorStorage.BlsPubkey == "" {
+			return false
+		}
+
+		blsPubkeys = append(blsPubkeys, anchorStorage.BlsPubkey)
+
+		accumulatedWeight += quorumWeights[member]
+
+	}
+
+	if accumulatedWeight < GetQuorumMajorityByWeights(quorumWeights) {
+		return false
+	}
+
+	return cryptography.VerifyBlsAggregatedSignature(dataThatShouldBeSigned, blsPubkeys, proof.AggregatedSignature)
+
+}
@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/modulrcloud/modulr-anchors-core/databases"
+	"github.com/modulrcloud/modulr-anchors-core/structures"
+)
+
+// Version of chaindata layout this node works with. Increase it together with adding migration to CHAINDATA_MIGRATIONS
+const CHAINDATA_SCHEMA_VERSION = 1
+
+// Key in APPROVEMENT_THREAD_METADATA with the version of stored chaindata
+var chaindataSchemaVersionKey = []byte("SCHEMA_VERSION")
+
+// ChaindataMigration moves data from schema Version-1 to Version. All its writes should go to batch,
+// so migration and version bump are committed together
+type ChaindataMigration struct {
+	Version     int
+	Description string
+	Migrate     func(batch *databases.AtomicBatch) error
+}
+
+// CHAINDATA_MIGRATIONS are ordered by version without gaps
+var CHAINDATA_MIGRATIONS = []ChaindataMigration{
+	{
+		Version:     1,
+		Description: "move generation thread metadata from legacy GT key to GT:<epochFullId>",
+		Migrate:     migrateLegacyGenerationThreadKey,
+	},
+}
+
+// ReadChaindataSchemaVersion returns stored version. Chaindata without version is 0, or the current version if node starts from genesis
+func ReadChaindataSchemaVersion() (int, error) {
+
+	raw, err := databases.APPROVEMENT_THREAD_METADATA.Get(chaindataSchemaVersionKey)
+
+	if err == nil {
+		return strconv.Atoi(string(raw))
+	}
+
+	if !errors.Is(err, databases.ErrNotFound) {
+		return 0, err
+	}
+
+	// Node which never started has no AT yet, it will be created in the current format
+
+	if hasAT, err := databases.APPROVEMENT_THREAD_METADATA.Has([]byte("AT")); err != nil || hasAT {
+		return 0, err
+	}
+
+	return CHAINDATA_SCHEMA_VERSION, nil
+
+}
+
+// MigrateChaindata applies pending migrations one by one. Each migration is committed atomically with the new version,
+// so interrupted upgrade continues from the last committed one. In dry-run mode migrations are only reported.
+// Node refuses to work with chaindata of newer schema
+func MigrateChaindata(dryRun bool) error {
+
+	version, err := ReadChaindataSchemaVersion()
+
+	if err != nil {
+		return fmt.Errorf("read chaindata schema version: %w", err)
+	}
+
+	if version > CHAINDATA_SCHEMA_VERSION {
+		return fmt.Errorf("chaindata schema version %d is newer than %d supported by this node, upgrade the node", version, CHAINDATA_SCHEMA_VERSION)
+	}
+
+	for _, migration := range CHAINDATA_MIGRATIONS {
+
+		if migration.Version <= version {
+			continue
+		}
+
+		batch := databases.NewAtomicBatch()
+
+		if err := migration.Migrate(batch); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Description, err)
+		}
+
+		if dryRun {
+			LogWithTime(fmt.Sprintf("[dry-run] Migration %d: %s - %d changes", migration.Version, migration.Description, batch.Len()), YELLOW_COLOR)
+			continue
+		}
+
+		batch.Put(databases.APPROVEMENT_THREAD_METADATA, chaindataSchemaVersionKey, []byte(strconv.Itoa(migration.Version)))
+
+		if err := batch.Write(); err != nil {
+			return fmt.Errorf("commit migration %d: %w", migration.Version, err)
+		}
+
+		LogWithTime(fmt.Sprintf("Migration %d applied: %s", migration.Version, migration.Description), GREEN_COLOR)
+
+	}
+
+	if dryRun {
+		return nil
+	}
+
+	// Fresh chaindata and chaindata without pending migrations just get the current version
+
+	return databases.APPROVEMENT_THREAD_METADATA.Put(chaindataSchemaVersionKey, []byte(strconv.Itoa(CHAINDATA_SCHEMA_VERSION)))
+
+}
+
+// migrateLegacyGenerationThreadKey gives legacy GT metadata to the oldest supported epoch without GT:<epochFullId>.
+// Legacy key is deleted only in the same batch with the new key, so GT is never lost
+func migrateLegacyGenerationThreadKey(batch *databases.AtomicBatch) error {
+
+	legacyData, err := databases.BLOCKS.Get([]byte("GT"))
+
+	if errors.Is(err, databases.ErrNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	rawAT, err := databases.APPROVEMENT_THREAD_METADATA.Get([]byte("AT"))
+
+	// Without AT there are no epochs to give GT to - legacy key stays as is
+
+	if errors.Is(err, databases.ErrNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	var atHandler structures.ApprovementThreadMetadataHandler
+
+	if err := json.Unmarshal(rawAT, &atHandler); err != nil {
+		return fmt.Errorf("unmarshal APPROVEMENT_THREAD metadata: %w", err)
+	}
+
+	var gtHandler structures.GenerationThreadMetadataHandler
+
+	if err := json.Unmarshal(legacyData, &gtHandler); err != nil {
+		return fmt.Errorf("unmarshal legacy GENERATION_THREAD metadata: %w", err)
+	}
+
+	for _, epoch := range atHandler.GetEpochHandlers() {
+
+		epochFullID := epoch.Hash + "#" + strconv.Itoa(epoch.Id)
+
+		has, err := databases.BLOCKS.Has([]byte("GT:" + epochFullID))
+
+		if err != nil {
+			return err
+		}
+
+		if has {
+			continue
+		}
+
+		gtHandler.EpochFullId = epochFullID
+
+		payload, err := json.Marshal(gtHandler)
+
+		if err != nil {
+			return err
+		}
+
+		batch.Put(databases.BLOCKS, []byte("GT:"+epochFullID), payload)
+
+		batch.Delete(databases.BLOCKS, []byte("GT"))
+
+		return nil
+
+	}
+
+	return nil
+
+}
@@ -0,0 +1,634 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/modulrcloud/modulr-anchors-core/databases"
+	"github.com/modulrcloud/modulr-anchors-core/globals"
+	"github.com/modulrcloud/modulr-anchors-core/structures"
+	"lukechampine.com/blake3"
+)
+
+// Snapshot archive is a gzip stream of: magic, header (uvarint length + JSON), records (uvarint length + key, uvarint length + value)
+// of the root store, uvarint 0 as the end of records and blake3 digest of everything before it. See docs/snapshots.md
+
+const CHAINDATA_SNAPSHOT_FORMAT_VERSION = 1
+
+// Number of records restored per batch on import
+const SNAPSHOT_IMPORT_BATCH_SIZE = 10000
+
+const chaindataSnapshotMagic = "MODULR_SNAPSHOT\n"
+
+// Limits to refuse broken archive before allocating memory for it
+const (
+	maxSnapshotHeaderSize = 64 << 20
+	maxSnapshotChunkSize  = 256 << 20
+)
+
+// ExportChaindataSnapshot writes all the stores to output as one archive. Records are read from a snapshot of the root store,
+// so the archive is consistent across all the stores. Returns header of the written archive
+func ExportChaindataSnapshot(output io.Writer) (*structures.ChaindataSnapshotHeader, error) {
+
+	version, err := ReadChaindataSchemaVersion()
+
+	if err != nil {
+		return nil, fmt.Errorf("read chaindata schema version: %w", err)
+	}
+
+	if version != CHAINDATA_SCHEMA_VERSION {
+		return nil, fmt.Errorf("chaindata schema version %d differs from %d of this node, run migrate first", version, CHAINDATA_SCHEMA_VERSION)
+	}
+
+	snapshot, err := databases.ROOT.NewSnapshot()
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer snapshot.Release()
+
+	header, err := buildSnapshotHeader(snapshot, version)
+
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(header)
+
+	if err != nil {
+		return nil, err
+	}
+
+	compressor := gzip.NewWriter(output)
+
+	hasher := blake3.New(32, nil)
+
+	writer := bufio.NewWriter(io.MultiWriter(compressor, hasher))
+
+	writer.WriteString(chaindataSnapshotMagic)
+
+	writeSnapshotChunk(writer, payload)
+
+	iterator := snapshot.NewIterator(nil)
+
+	defer iterator.Release()
+
+	for iterator.Next() {
+
+		writeSnapshotChunk(writer, iterator.Key())
+
+		writeSnapshotChunk(writer, iterator.Value())
+
+	}
+
+	if err := iterator.Error(); err != nil {
+		return nil, err
+	}
+
+	// Keys are never empty (each has namespace prefix), so zero length marks the end of records
+
+	writeSnapshotChunk(writer, nil)
+
+	if err := writer.Flush(); err != nil {
+		return nil, err
+	}
+
+	if _, err := compressor.Write(hasher.Sum(nil)); err != nil {
+		return nil, err
+	}
+
+	return header, compressor.Close()
+
+}
+
+func buildSnapshotHeader(snapshot databases.Snapshot, schemaVersion int) (*structures.ChaindataSnapshotHeader, error) {
+
+	rawAT, err := snapshot.Get(snapshotKey("APPROVEMENT_THREAD_METADATA", "AT"))
+
+	if err != nil {
+		return nil, fmt.Errorf("read APPROVEMENT_THREAD metadata: %w", err)
+	}
+
+	var atHandler structures.ApprovementThreadMetadataHandler
+
+	if err := json.Unmarshal(rawAT, &atHandler); err != nil {
+		return nil, fmt.Errorf("unmarshal APPROVEMENT_THREAD metadata: %w", err)
+	}
+
+	header := &structures.ChaindataSnapshotHeader{
+		FormatVersion:     CHAINDATA_SNAPSHOT_FORMAT_VERSION,
+		SchemaVersion:     schemaVersion,
+		NetworkId:         globals.GENESIS.NetworkId,
+		ExporterPubkey:    globals.CONFIGURATION.PublicKey,
+		CreatedAt:         GetUTCTimestampInMilliSeconds(),
+		ApprovementThread: rawAT,
+		EpochHandlers:     make(map[int]structures.EpochDataHandler),
+	}
+
+	handlerPrefix := snapshotKey("EPOCH_DATA", "EPOCH_HANDLER:")
+
+	iterator := snapshot.NewIterator(nil)
+
+	defer iterator.Release()
+
+	for iterator.Next() {
+
+		header.Records++
+
+		if !bytes.HasPrefix(iterator.Key(), handlerPrefix) {
+			continue
+		}
+
+		var epochHandler structures.EpochDataHandler
+
+		if err := json.Unmarshal(iterator.Value(), &epochHandler); err != nil {
+			return nil, fmt.Errorf("unmarshal %s: %w", iterator.Key(), err)
+		}
+
+		header.EpochHandlers[epochHandler.Id] = epochHandler
+
+	}
+
+	if err := iterator.Error(); err != nil {
+		return nil, err
+	}
+
+	// Supported epochs are always in AT, even if their handlers weren't stored separately
+
+	for _, epochHandler := range atHandler.GetEpochHandlers() {
+		if _, ok := header.EpochHandlers[epochHandler.Id]; !ok {
+			header.EpochHandlers[epochHandler.Id] = epochHandler
+		}
+	}
+
+	return header, nil
+
+}
+
+// VerifyChaindataSnapshot reads the whole archive and checks its checksum, header and that AT record matches the header.
+// AFPs and epoch handlers are verified on import, because their verification needs the restored chaindata
+func VerifyChaindataSnapshot(path string) (*structures.ChaindataSnapshotHeader, error) {
+
+	reader, err := openSnapshot(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer reader.close()
+
+	atKey := snapshotKey("APPROVEMENT_THREAD_METADATA", "AT")
+
+	records, hasAT := 0, false
+
+	for {
+
+		key, value, err := reader.next()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if key == nil {
+			break
+		}
+
+		records++
+
+		if _, _, err := splitSnapshotKey(key); err != nil {
+			return nil, err
+		}
+
+		if bytes.Equal(key, atKey) {
+
+			if !equalJSON(value, reader.header.ApprovementThread) {
+				return nil, errors.New("AT record differs from the snapshot header")
+			}
+
+			hasAT = true
+
+		}
+
+	}
+
+	if err := reader.verifyChecksum(); err != nil {
+		return nil, err
+	}
+
+	if records != reader.header.Records {
+		return nil, fmt.Errorf("snapshot has %d records, header says %d", records, reader.header.Records)
+	}
+
+	if !hasAT {
+		return nil, errors.New("snapshot has no AT record")
+	}
+
+	return &reader.header, nil
+
+}
+
+// ImportChaindataSnapshot restores archive to the empty root store. Archive is verified with VerifyChaindataSnapshot first,
+// then records are restored and each AFP is verified with epoch handler from the header. Caller verifies the header handlers
+// against the local genesis with block_pack.VerifyEpochHandlersChain and removes the store on error. If snapshot was exported by another
+// anchor, its node-local data (generation thread, in-flight blocks, mempool, inclusion index, proofs grabbers) is skipped.
+// Returns header and number of restored records
+func ImportChaindataSnapshot(path string) (*structures.ChaindataSnapshotHeader, int, error) {
+
+	header, err := VerifyChaindataSnapshot(path)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	iterator := databases.ROOT.NewIterator(nil)
+
+	notEmpty := iterator.Next()
+
+	iterator.Release()
+
+	if notEmpty {
+		return nil, 0, errors.New("chaindata is not empty, snapshot can be imported only to empty CHAINDATA_PATH")
+	}
+
+	reader, err := openSnapshot(path)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer reader.close()
+
+	skipNodeLocal := header.ExporterPubkey != globals.CONFIGURATION.PublicKey
+
+	batch := new(databases.Batch)
+
+	namespace, restored := "", 0
+
+	for {
+
+		key, value, err := reader.next()
+
+		if err != nil {
+			return nil, restored, err
+		}
+
+		if key == nil {
+			break
+		}
+
+		keyNamespace, localKey, err := splitSnapshotKey(key)
+
+		if err != nil {
+			return nil, restored, err
+		}
+
+		// BLS AFPs are verified with keys from anchors storage, so the batch is written each time namespace changes
+		// (APPROVEMENT_THREAD_METADATA goes before EPOCH_DATA)
+
+		if keyNamespace != namespace {
+
+			if err := writeSnapshotBatch(batch); err != nil {
+				return nil, restored, err
+			}
+
+			namespace = keyNamespace
+
+		}
+
+		if (skipNodeLocal && isNodeLocalKey(keyNamespace, localKey)) || isLocallyDerivedKey(keyNamespace, localKey) {
+			continue
+		}
+
+		// Empty "AFP:" is stored together with the first block of creator, which has no previous block
+
+		if keyNamespace == "EPOCH_DATA" && strings.HasPrefix(localKey, "AFP:") && localKey != "AFP:" {
+
+			if err := verifySnapshotAfp(strings.TrimPrefix(localKey, "AFP:"), value, header); err != nil {
+				return nil, restored, err
+			}
+
+		}
+
+		batch.Put(key, value)
+
+		restored++
+
+		if batch.Len() >= SNAPSHOT_IMPORT_BATCH_SIZE {
+
+			if err := writeSnapshotBatch(batch); err != nil {
+				return nil, restored, err
+			}
+
+		}
+
+	}
+
+	return header, restored, writeSnapshotBatch(batch)
+
+}
+
+func writeSnapshotBatch(batch *databases.Batch) error {
+
+	if batch.Len() == 0 {
+		return nil
+	}
+
+	if err := databases.ROOT.Write(batch); err != nil {
+		return err
+	}
+
+	batch.Reset()
+
+	return nil
+
+}
+
+func verifySnapshotAfp(blockId string, value []byte, header *structures.ChaindataSnapshotHeader) error {
+
+	var afp structures.AggregatedFinalizationProof
+
+	if err := json.Unmarshal(value, &afp); err != nil {
+		return fmt.Errorf("AFP %s: %w", blockId, err)
+	}
+
+	if afp.BlockId != blockId {
+		return fmt.Errorf("AFP for block %s is stored as AFP %s", afp.BlockId, blockId)
+	}
+
+	epochIndex, err := strconv.Atoi(strings.SplitN(blockId, ":", 2)[0])
+
+	if err != nil {
+		return fmt.Errorf("AFP %s: bad block id", blockId)
+	}
+
+	epochHandler, ok := header.EpochHandlers[epochIndex]
+
+	if !ok {
+		return fmt.Errorf("AFP %s: snapshot has no handler of epoch %d", blockId, epochIndex)
+	}
+
+	if !VerifyAggregatedFinalizationProof(&afp, &epochHandler) {
+		return fmt.Errorf("AFP %s is invalid", blockId)
+	}
+
+	return nil
+
+}
+
+// isNodeLocalKey reports whether record belongs to the node which exported it rather than to chain
+func isNodeLocalKey(namespace, key string) bool {
+
+	switch namespace {
+
+	case "BLOCKS":
+		return strings.HasPrefix(key, "GT:") || strings.HasPrefix(key, "IN_FLIGHT:") || strings.HasPrefix(key, globals.MEMPOOL_KEY_PREFIX)
+
+	case "EPOCH_DATA":
+		return strings.HasPrefix(key, "INCLUDED:")
+
+	case "FINALIZATION_VOTING_STATS":
+		return strings.HasSuffix(key, ":PROOFS_GRABBER")
+
+	}
+
+	return false
+
+}
+
+// isLocallyDerivedKey reports whether record is derived by node itself and shouldn't be taken from archive
+func isLocallyDerivedKey(namespace, key string) bool {
+
+	switch namespace {
+
+	// Changes of epoch are collected again from the epoch finish proof and blocks
+
+	case "EPOCH_DATA":
+		return strings.HasPrefix(key, "EPOCH_CHANGES:")
+
+	// Pruned epoch is found by data which is really in snapshot, see block_pack.VerifyEpochHandlersChain
+
+	case "APPROVEMENT_THREAD_METADATA":
+		return key == "PRUNED_EPOCH"
+
+	}
+
+	return false
+
+}
+
+func snapshotKey(namespace, key string) []byte {
+	return []byte(namespace + databases.NAMESPACE_SEPARATOR + key)
+}
+
+func splitSnapshotKey(key []byte) (string, string, error) {
+
+	namespace, localKey, found := strings.Cut(string(key), databases.NAMESPACE_SEPARATOR)
+
+	if !found || !slices.Contains(databases.NAMESPACES, namespace) {
+		return "", "", fmt.Errorf("record %q doesn't belong to any store", key)
+	}
+
+	return namespace, localKey, nil
+
+}
+
+func writeSnapshotChunk(writer *bufio.Writer, data []byte) {
+
+	writer.Write(binary.AppendUvarint(nil, uint64(len(data))))
+
+	writer.Write(data)
+
+}
+
+func equalJSON(first, second []byte) bool {
+
+	var compactFirst, compactSecond bytes.Buffer
+
+	if json.Compact(&compactFirst, first) != nil || json.Compact(&compactSecond, second) != nil {
+		return false
+	}
+
+	return bytes.Equal(compactFirst.Bytes(), compactSecond.Bytes())
+
+}
+
+// snapshotReader hashes everything it reads, so the digest at the end of archive can be compared
+type snapshotReader struct {
+	file         *os.File
+	decompressor *gzip.Reader
+	source       *bufio.Reader
+	hasher       *blake3.Hasher
+	header       structures.ChaindataSnapshotHeader
+}
+
+// openSnapshot reads magic and header of archive and checks that it can be imported by this node
+func openSnapshot(path string) (*snapshotReader, error) {
+
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	decompressor, err := gzip.NewReader(file)
+
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%s is not a snapshot: %w", path, err)
+	}
+
+	reader := &snapshotReader{file: file, decompressor: decompressor, source: bufio.NewReader(decompressor), hasher: blake3.New(32, nil)}
+
+	magic := make([]byte, len(chaindataSnapshotMagic))
+
+	if _, err := io.ReadFull(reader, magic); err != nil || string(magic) != chaindataSnapshotMagic {
+		reader.close()
+		return nil, fmt.Errorf("%s is not a snapshot", path)
+	}
+
+	payload, err := reader.readChunk(maxSnapshotHeaderSize)
+
+	if err == nil {
+		err = json.Unmarshal(payload, &reader.header)
+	}
+
+	if err != nil {
+		reader.close()
+		return nil, fmt.Errorf("read snapshot header: %w", err)
+	}
+
+	if err := checkSnapshotHeader(&reader.header); err != nil {
+		reader.close()
+		return nil, err
+	}
+
+	return reader, nil
+
+}
+
+func checkSnapshotHeader(header *structures.ChaindataSnapshotHeader) error {
+
+	if header.FormatVersion != CHAINDATA_SNAPSHOT_FORMAT_VERSION {
+		return fmt.Errorf("snapshot format version %d is not supported, expected %d", header.FormatVersion, CHAINDATA_SNAPSHOT_FORMAT_VERSION)
+	}
+
+	if header.SchemaVersion > CHAINDATA_SCHEMA_VERSION {
+		return fmt.Errorf("snapshot schema version %d is newer than %d supported by this node, upgrade the node", header.SchemaVersion, CHAINDATA_SCHEMA_VERSION)
+	}
+
+	if header.NetworkId != globals.GENESIS.NetworkId {
+		return fmt.Errorf("snapshot is for network %s, node is in %s", header.NetworkId, globals.GENESIS.NetworkId)
+	}
+
+	return nil
+
+}
+
+func (reader *snapshotReader) Read(buffer []byte) (int, error) {
+
+	read, err := reader.source.Read(buffer)
+
+	reader.hasher.Write(buffer[:read])
+
+	return read, err
+
+}
+
+func (reader *snapshotReader) ReadByte() (byte, error) {
+
+	value, err := reader.source.ReadByte()
+
+	if err == nil {
+		reader.hasher.Write([]byte{value})
+	}
+
+	return value, err
+
+}
+
+func (reader *snapshotReader) readChunk(limit uint64) ([]byte, error) {
+
+	length, err := binary.ReadUvarint(reader)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if length > limit {
+		return nil, fmt.Errorf("record of %d bytes is too big", length)
+	}
+
+	data := make([]byte, length)
+
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+
+}
+
+// next returns the next record or nil key after the last one
+func (reader *snapshotReader) next() ([]byte, []byte, error) {
+
+	key, err := reader.readChunk(maxSnapshotChunkSize)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("read snapshot record: %w", err)
+	}
+
+	if len(key) == 0 {
+		return nil, nil, nil
+	}
+
+	value, err := reader.readChunk(maxSnapshotChunkSize)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("read snapshot record %s: %w", key, err)
+	}
+
+	return key, value, nil
+
+}
+
+// verifyChecksum should be called after the last record
+func (reader *snapshotReader) verifyChecksum() error {
+
+	expected := reader.hasher.Sum(nil)
+
+	digest := make([]byte, len(expected))
+
+	if _, err := io.ReadFull(reader.source, digest); err != nil {
+		return fmt.Errorf("read snapshot checksum: %w", err)
+	}
+
+	if !bytes.Equal(digest, expected) {
+		return errors.New("snapshot checksum mismatch, archive is corrupted")
+	}
+
+	if _, err := reader.source.ReadByte(); err != io.EOF {
+		return errors.New("unexpected data after snapshot checksum")
+	}
+
+	return nil
+
+}
+
+func (reader *snapshotReader) close() {
+
+	reader.decompressor.Close()
+
+	reader.file.Close()
+
+}
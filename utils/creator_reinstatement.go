@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/modulrcloud/modulr-anchors-core/cryptography"
+	"github.com/modulrcloud/modulr-anchors-core/databases"
+	"github.com/modulrcloud/modulr-anchors-core/globals"
+	"github.com/modulrcloud/modulr-anchors-core/structures"
+)
+
+// verifyReinstatementAfp checks that AFP finalizes the block of creator in epoch and returns the block index
+func verifyReinstatementAfp(epochHandler *structures.EpochDataHandler, creator string, afp *structures.AggregatedFinalizationProof) (int, error) {
+
+	blockParts := strings.Split(afp.BlockId, ":")
+
+	if len(blockParts) != 3 || blockParts[0] != strconv.Itoa(epochHandler.Id) || blockParts[1] != creator {
+		return 0, errors.New("AFP blockId mismatch")
+	}
+
+	index, err := strconv.Atoi(blockParts[2])
+
+	if err != nil || index < 0 {
+		return 0, errors.New("invalid AFP block index")
+	}
+
+	if !VerifyAggregatedFinalizationProof(afp, epochHandler) {
+		return 0, errors.New("invalid aggregated finalization proof")
+	}
+
+	return index, nil
+
+}
+
+// storeVotingStatFromAfp moves local voting stat of creator forward if AFP is fresher. Creator mutex should be locked
+func storeVotingStatFromAfp(epochIndex int, creator string, index int, afp *structures.AggregatedFinalizationProof) error {
+
+	localStat, err := ReadVotingStat(epochIndex, creator)
+
+	if err != nil {
+		return err
+	}
+
+	if index <= localStat.Index {
+		return nil
+	}
+
+	return StoreVotingStat(epochIndex, creator, structures.VotingStat{Index: index, Hash: afp.BlockHash, Afp: *afp})
+
+}
+
+// ProcessCreatorReinstatementRequest signs reinstatement of creator if its AFP goes beyond the height where we considered it stalled.
+// Creators with aggregated anchor rotation proof are never reinstated - rotation is final
+func ProcessCreatorReinstatementRequest(req *structures.CreatorReinstatementRequest, epochHandler *structures.EpochDataHandler) structures.CreatorReinstatementResponse {
+
+	if !slices.Contains(epochHandler.AnchorsRegistry, req.Creator) {
+		return structures.CreatorReinstatementResponse{Status: "ERROR", Message: "creator is not part of epoch"}
+	}
+
+	creatorMutex := globals.BLOCK_CREATORS_MUTEX_REGISTRY.GetMutex(epochHandler.Id, req.Creator)
+
+	creatorMutex.Lock()
+
+	defer creatorMutex.Unlock()
+
+	if HasAggregatedAnchorRotationProof(epochHandler.Id, req.Creator) {
+		return structures.CreatorReinstatementResponse{Status: "REJECTED", Message: "creator was already rotated"}
+	}
+
+	index, err := verifyReinstatementAfp(epochHandler, req.Creator, &req.Afp)
+
+	if err != nil {
+		return structures.CreatorReinstatementResponse{Status: "ERROR", Message: err.Error()}
+	}
+
+	if status, disabled := ReadBlockCreatorHealthStatus(epochHandler.Id, req.Creator); disabled && index <= status.StalledIndex {
+		return structures.CreatorReinstatementResponse{Status: "REJECTED", Message: "AFP does not go beyond the stalled height"}
+	}
+
+	if err := storeVotingStatFromAfp(epochHandler.Id, req.Creator, index, &req.Afp); err != nil {
+		return structures.CreatorReinstatementResponse{Status: "ERROR", Message: "failed to persist voting stat"}
+	}
+
+	epochFullID := epochHandler.Hash + "#" + strconv.Itoa(epochHandler.Id)
+
+	return structures.CreatorReinstatementResponse{
+		Status:    "OK",
+		Signature: cryptography.GenerateSignature(globals.CONFIGURATION.PrivateKey, GetCreatorReinstatementSigningData(epochHandler.Id, req.Creator, &req.Afp, epochFullID)),
+	}
+
+}
+
+func VerifyAggregatedCreatorReinstatementProof(proof *structures.AggregatedCreatorReinstatementProof, epochHandler *structures.EpochDataHandler) error {
+
+	if proof.EpochIndex != epochHandler.Id || !slices.Contains(epochHandler.AnchorsRegistry, proof.Creator) {
+		return errors.New("creator is not part of epoch")
+	}
+
+	if _, err := verifyReinstatementAfp(epochHandler, proof.Creator, &proof.Afp); err != nil {
+		return err
+	}
+
+	epochFullID := epochHandler.Hash + "#" + strconv.Itoa(epochHandler.Id)
+
+	dataThatShouldBeSigned := GetCreatorReinstatementSigningData(proof.EpochIndex, proof.Creator, &proof.Afp, epochFullID)
+
+	validSignatures := make(map[string]string)
+
+	for signer, signature := range proof.Signatures {
+		if slices.Contains(epochHandler.Quorum, signer) && cryptography.VerifySignature(dataThatShouldBeSigned, signer, signature) {
+			validSignatures[signer] = signature
+		}
+	}
+
+	quorumWeights := GetQuorumWeights(epochHandler)
+
+	if GetSignaturesWeight(quorumWeights, validSignatures) < GetQuorumMajorityByWeights(quorumWeights) {
+		return errors.New("not enough valid signatures")
+	}
+
+	return nil
+
+}
+
+// ApplyAggregatedCreatorReinstatementProof clears the health flag of creator unless it was already rotated.
+// Proof is applied only if its AFP goes beyond the height where we disabled the creator, so old proofs can't be replayed
+func ApplyAggregatedCreatorReinstatementProof(proof *structures.AggregatedCreatorReinstatementProof, epochHandler *structures.EpochDataHandler) error {
+
+	if err := VerifyAggregatedCreatorReinstatementProof(proof, epochHandler); err != nil {
+		return err
+	}
+
+	creatorMutex := globals.BLOCK_CREATORS_MUTEX_REGISTRY.GetMutex(proof.EpochIndex, proof.Creator)
+
+	creatorMutex.Lock()
+
+	defer creatorMutex.Unlock()
+
+	if HasAggregatedAnchorRotationProof(proof.EpochIndex, proof.Creator) {
+		return errors.New("creator was already rotated")
+	}
+
+	// BlockId format was checked during verification
+
+	blockParts := strings.Split(proof.Afp.BlockId, ":")
+
+	index, _ := strconv.Atoi(blockParts[2])
+
+	if status, disabled := ReadBlockCreatorHealthStatus(proof.EpochIndex, proof.Creator); disabled && index <= status.StalledIndex {
+		return errors.New("AFP does not go beyond the stalled height")
+	}
+
+	if err := storeVotingStatFromAfp(proof.EpochIndex, proof.Creator, index, &proof.Afp); err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(proof)
+
+	if err != nil {
+		return err
+	}
+
+	if err := databases.FINALIZATION_VOTING_STATS.Put([]byte("CREATOR_REINSTATEMENT:"+strconv.Itoa(proof.EpochIndex)+":"+proof.Creator), payload); err != nil {
+		return err
+	}
+
+	return EnableFinalizationProofsForCreator(proof.EpochIndex, proof.Creator)
+
+}
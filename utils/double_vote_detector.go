@@ -0,0 +1,226 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/modulrcloud/modulr-anchors-core/cryptography"
+	"github.com/modulrcloud/modulr-anchors-core/databases"
+	"github.com/modulrcloud/modulr-anchors-core/globals"
+	"github.com/modulrcloud/modulr-anchors-core/structures"
+)
+
+// Index of seen finalization votes. Structure is epochIndex => voter:blockId => vote
+var finalizationVotesIndex = struct {
+	sync.Mutex
+	Data map[int]map[string]structures.FinalizationVote
+}{
+	Data: make(map[int]map[string]structures.FinalizationVote),
+}
+
+// RecordFinalizationVote indexes the already verified finalization proof of voter. If voter signed another hash
+// for the same block id before - double vote evidence is stored and added to mempool to be included to blocks.
+//
+// Known gap: detection is disabled in BLS networks. AFPs keep only the aggregated signature and the signers bitmap,
+// so a single vote can't be extracted to the evidence, and the evidence format verifies only ed25519 votes.
+// Quorum members are still protected from signing two hashes themselves by the slashing protection journal
+func RecordFinalizationVote(epochHandler *structures.EpochDataHandler, voter, blockId string, vote structures.FinalizationVote) {
+
+	if globals.GENESIS.UsesBlsFinalizationProofs() {
+		return
+	}
+
+	finalizationVotesIndex.Lock()
+
+	votesOfEpoch, ok := finalizationVotesIndex.Data[epochHandler.Id]
+
+	if !ok {
+		votesOfEpoch = make(map[string]structures.FinalizationVote)
+		finalizationVotesIndex.Data[epochHandler.Id] = votesOfEpoch
+	}
+
+	indexKey := voter + ":" + blockId
+
+	seenVote, seen := votesOfEpoch[indexKey]
+
+	if !seen {
+		votesOfEpoch[indexKey] = vote
+	}
+
+	finalizationVotesIndex.Unlock()
+
+	if !seen || seenVote.BlockHash == vote.BlockHash {
+		return
+	}
+
+	evidence := structures.DoubleVoteEvidence{
+		EpochIndex:            epochHandler.Id,
+		EpochFullID:           epochHandler.Hash + "#" + strconv.Itoa(epochHandler.Id),
+		SigningPayloadVersion: epochHandler.SigningPayloadVersion,
+		Voter:                 voter,
+		BlockId:               blockId,
+		FirstVote:             seenVote,
+		SecondVote:            vote,
+	}
+
+	if HasDoubleVoteEvidence(&evidence) || VerifyDoubleVoteEvidenceSignatures(&evidence) != nil {
+		return
+	}
+
+	batch := databases.NewAtomicBatch()
+
+	if err := StoreDoubleVoteEvidence(evidence, batch); err != nil {
+		LogWithTime("Failed to store double vote evidence for "+blockId+": "+err.Error(), RED_COLOR)
+		return
+	}
+
+	if err := batch.Write(); err != nil {
+		LogWithTime("Failed to store double vote evidence for "+blockId+": "+err.Error(), RED_COLOR)
+		return
+	}
+
+	if err := globals.MEMPOOL.AddDoubleVoteEvidence(evidence); err != nil {
+		LogWithTime("Failed to add double vote evidence to mempool: "+err.Error(), RED_COLOR)
+	}
+
+	LogWithTime("Double vote detected: "+voter+" signed two different hashes for block "+blockId, RED_COLOR)
+
+}
+
+// RecordAggregatedFinalizationProofVotes indexes votes of accepted AFP. AFP is accepted when majority of its signatures is valid,
+// so each signature is checked again before it's indexed
+func RecordAggregatedFinalizationProofVotes(proof *structures.AggregatedFinalizationProof, epochHandler *structures.EpochDataHandler) {
+
+	if proof.AggregatedSignature != "" {
+		return
+	}
+
+	epochFullID := epochHandler.Hash + "#" + strconv.Itoa(epochHandler.Id)
+
+	dataThatShouldBeSigned := GetFinalizationProofSigningData(epochHandler.SigningPayloadVersion, proof.PrevBlockHash, proof.BlockId, proof.BlockHash, epochFullID)
+
+	for voter, signature := range proof.Proofs {
+
+		if slices.Contains(epochHandler.Quorum, voter) && cryptography.VerifySignature(dataThatShouldBeSigned, voter, signature) {
+
+			RecordFinalizationVote(epochHandler, voter, proof.BlockId, structures.FinalizationVote{
+				PrevBlockHash: proof.PrevBlockHash,
+				BlockHash:     proof.BlockHash,
+				Signature:     signature,
+			})
+
+		}
+
+	}
+
+}
+
+// ForgetFinalizationVotes drops the index of epoch which is no longer supported
+func ForgetFinalizationVotes(epochIndex int) {
+
+	finalizationVotesIndex.Lock()
+	delete(finalizationVotesIndex.Data, epochIndex)
+	finalizationVotesIndex.Unlock()
+
+}
+
+// VerifyDoubleVoteEvidenceSignatures checks the evidence itself - both votes should be valid signatures of voter over different hashes
+func VerifyDoubleVoteEvidenceSignatures(evidence *structures.DoubleVoteEvidence) error {
+
+	if evidence.FirstVote.BlockHash == evidence.SecondVote.BlockHash {
+		return errors.New("votes are for the same hash")
+	}
+
+	if !IsSupportedSigningPayloadVersion(evidence.SigningPayloadVersion) {
+		return errors.New("unsupported signing payload version")
+	}
+
+	for _, vote := range []structures.FinalizationVote{evidence.FirstVote, evidence.SecondVote} {
+
+		dataThatShouldBeSigned := GetFinalizationProofSigningData(evidence.SigningPayloadVersion, vote.PrevBlockHash, evidence.BlockId, vote.BlockHash, evidence.EpochFullID)
+
+		if !cryptography.VerifySignature(dataThatShouldBeSigned, evidence.Voter, vote.Signature) {
+			return errors.New("invalid vote signature")
+		}
+
+	}
+
+	return nil
+
+}
+
+// VerifyDoubleVoteEvidence checks that evidence relates to the provided epoch and voter is its quorum member
+func VerifyDoubleVoteEvidence(evidence *structures.DoubleVoteEvidence, epochHandler *structures.EpochDataHandler) error {
+
+	if evidence.EpochIndex != epochHandler.Id || evidence.EpochFullID != epochHandler.Hash+"#"+strconv.Itoa(epochHandler.Id) {
+		return errors.New("evidence epoch mismatch")
+	}
+
+	if evidence.SigningPayloadVersion != epochHandler.SigningPayloadVersion {
+		return errors.New("signing payload version mismatch")
+	}
+
+	if !slices.Contains(epochHandler.Quorum, evidence.Voter) {
+		return errors.New("voter is not a quorum member")
+	}
+
+	return VerifyDoubleVoteEvidenceSignatures(evidence)
+
+}
+
+func doubleVoteKey(evidence *structures.DoubleVoteEvidence) []byte {
+	return []byte("DOUBLE_VOTE:" + evidence.BlockId + ":" + evidence.Voter)
+}
+
+func HasDoubleVoteEvidence(evidence *structures.DoubleVoteEvidence) bool {
+	if _, err := databases.EPOCH_DATA.Get(doubleVoteKey(evidence)); err == nil {
+		return true
+	}
+	return false
+}
+
+// StoreDoubleVoteEvidence keeps the first evidence for each voter and block id
+func StoreDoubleVoteEvidence(evidence structures.DoubleVoteEvidence, batch *databases.AtomicBatch) error {
+
+	if HasDoubleVoteEvidence(&evidence) {
+		return nil
+	}
+
+	payload, err := json.Marshal(evidence)
+
+	if err != nil {
+		return err
+	}
+
+	batch.Put(databases.EPOCH_DATA, doubleVoteKey(&evidence), payload)
+
+	return nil
+
+}
+
+func LoadDoubleVoteEvidences(epochIndex int) ([]structures.DoubleVoteEvidence, error) {
+
+	iterator := databases.EPOCH_DATA.NewIterator([]byte("DOUBLE_VOTE:" + strconv.Itoa(epochIndex) + ":"))
+
+	defer iterator.Release()
+
+	evidences := []structures.DoubleVoteEvidence{}
+
+	for iterator.Next() {
+
+		var evidence structures.DoubleVoteEvidence
+
+		if err := json.Unmarshal(iterator.Value(), &evidence); err != nil {
+			return nil, err
+		}
+
+		evidences = append(evidences, evidence)
+
+	}
+
+	return evidences, iterator.Error()
+
+}
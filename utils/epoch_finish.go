@@ -0,0 +1,309 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/modulrcloud/modulr-anchors-core/cryptography"
+	"github.com/modulrcloud/modulr-anchors-core/databases"
+	"github.com/modulrcloud/modulr-anchors-core/globals"
+	"github.com/modulrcloud/modulr-anchors-core/structures"
+)
+
+func aggregatedEpochFinishProofKey(epochIndex int) []byte {
+	return []byte("EPOCH_FINISH_PROOF:" + strconv.Itoa(epochIndex))
+}
+
+func StoreAggregatedEpochFinishProof(proof structures.AggregatedEpochFinishProof) error {
+
+	payload, err := json.Marshal(proof)
+
+	if err != nil {
+		return err
+	}
+
+	return databases.EPOCH_DATA.Put(aggregatedEpochFinishProofKey(proof.Summary.EpochIndex), payload)
+
+}
+
+func LoadAggregatedEpochFinishProof(epochIndex int) (structures.AggregatedEpochFinishProof, error) {
+
+	var proof structures.AggregatedEpochFinishProof
+
+	raw, err := databases.EPOCH_DATA.Get(aggregatedEpochFinishProofKey(epochIndex))
+
+	if err != nil {
+		return proof, err
+	}
+
+	err = json.Unmarshal(raw, &proof)
+
+	return proof, err
+
+}
+
+func HasAggregatedEpochFinishProof(epochIndex int) bool {
+	if _, err := databases.EPOCH_DATA.Get(aggregatedEpochFinishProofKey(epochIndex)); err == nil {
+		return true
+	}
+	return false
+}
+
+// readOwnFinalizedStat returns the last block of our node which received AFP in epoch. Our node may know it before others
+// because AFP for own block is collected locally by proofs grabber
+func readOwnFinalizedStat(epochIndex int) (structures.VotingStat, bool) {
+
+	var grabber struct {
+		HuntingForBlockId   string
+		HuntingForBlockHash string
+	}
+
+	rawGrabber, err := databases.FINALIZATION_VOTING_STATS.Get([]byte(strconv.Itoa(epochIndex) + ":PROOFS_GRABBER"))
+
+	if err != nil || json.Unmarshal(rawGrabber, &grabber) != nil || grabber.HuntingForBlockId == "" {
+		return structures.VotingStat{}, false
+	}
+
+	rawAfp, err := databases.EPOCH_DATA.Get([]byte("AFP:" + grabber.HuntingForBlockId))
+
+	if err != nil {
+		return structures.VotingStat{}, false
+	}
+
+	var afp structures.AggregatedFinalizationProof
+
+	if json.Unmarshal(rawAfp, &afp) != nil || afp.BlockHash != grabber.HuntingForBlockHash {
+		return structures.VotingStat{}, false
+	}
+
+	blockParts := strings.Split(afp.BlockId, ":")
+
+	index, err := strconv.Atoi(blockParts[len(blockParts)-1])
+
+	if err != nil {
+		return structures.VotingStat{}, false
+	}
+
+	return structures.VotingStat{Index: index, Hash: afp.BlockHash, Afp: afp}, true
+
+}
+
+func readEpochFinishVotingStat(epochIndex int, creator string) (structures.VotingStat, error) {
+
+	stat, err := ReadVotingStat(epochIndex, creator)
+
+	if err != nil {
+		return stat, err
+	}
+
+	if creator == globals.CONFIGURATION.PublicKey {
+		if ownStat, ok := readOwnFinalizedStat(epochIndex); ok && ownStat.Index > stat.Index {
+			return ownStat, nil
+		}
+	}
+
+	return stat, nil
+
+}
+
+// BuildLocalEpochFinishSummary collects the local view on the last finalized block of each creator of epoch
+func BuildLocalEpochFinishSummary(epochHandler *structures.EpochDataHandler) (structures.EpochFinishSummary, error) {
+
+	summary := structures.EpochFinishSummary{
+		EpochIndex:  epochHandler.Id,
+		VotingStats: make(map[string]structures.VotingStat, len(epochHandler.AnchorsRegistry)),
+	}
+
+	for _, creator := range epochHandler.AnchorsRegistry {
+
+		stat, err := readEpochFinishVotingStat(epochHandler.Id, creator)
+
+		if err != nil {
+			return summary, err
+		}
+
+		summary.VotingStats[creator] = stat
+
+	}
+
+	return summary, nil
+
+}
+
+// VerifyEpochFinishVotingStat checks that stat points to the block of creator in epoch finalized by AFP
+func VerifyEpochFinishVotingStat(epochHandler *structures.EpochDataHandler, creator string, stat *structures.VotingStat) error {
+
+	if stat.Index == -1 {
+		if stat.Hash != structures.NewVotingStatTemplate().Hash {
+			return errors.New("invalid hash of empty stat")
+		}
+		return nil
+	}
+
+	if stat.Index < -1 || !strings.EqualFold(stat.Hash, stat.Afp.BlockHash) {
+		return errors.New("stat hash does not match AFP block hash")
+	}
+
+	if stat.Afp.BlockId != strconv.Itoa(epochHandler.Id)+":"+creator+":"+strconv.Itoa(stat.Index) {
+		return errors.New("AFP blockId mismatch")
+	}
+
+	if !VerifyAggregatedFinalizationProof(&stat.Afp, epochHandler) {
+		return errors.New("invalid aggregated finalization proof")
+	}
+
+	return nil
+
+}
+
+func validateEpochFinishSummaryShape(summary *structures.EpochFinishSummary, epochHandler *structures.EpochDataHandler) error {
+
+	if summary.EpochIndex != epochHandler.Id {
+		return errors.New("summary epoch mismatch")
+	}
+
+	if len(summary.VotingStats) != len(epochHandler.AnchorsRegistry) {
+		return errors.New("summary should contain each creator of epoch")
+	}
+
+	for _, creator := range epochHandler.AnchorsRegistry {
+		if _, ok := summary.VotingStats[creator]; !ok {
+			return errors.New("summary should contain each creator of epoch")
+		}
+	}
+
+	return nil
+
+}
+
+// ProcessEpochFinishVote compares the summary with local voting stats. If we know fresher stats - they are returned with UPGRADE
+// status. Fresher stats from summary are verified and stored locally. Once summary matches our view we stop voting in epoch
+// (EPOCH_FINISH flag) and sign the summary, so the majority can't finalize blocks beyond the summary anymore.
+// Only one summary is signed per epoch (see slashing protection), so two different proofs for the same epoch can't be collected
+func ProcessEpochFinishVote(summary *structures.EpochFinishSummary, epochHandler *structures.EpochDataHandler) structures.EpochFinishVoteResponse {
+
+	if err := validateEpochFinishSummaryShape(summary, epochHandler); err != nil {
+		return structures.EpochFinishVoteResponse{Status: "ERROR", Message: err.Error()}
+	}
+
+	epochFullID := epochHandler.Hash + "#" + strconv.Itoa(epochHandler.Id)
+
+	dataThatShouldBeSigned := GetEpochFinishSigningData(summary, epochFullID)
+
+	summaryHash := Blake3(dataThatShouldBeSigned)
+
+	// Once we signed a summary, local stats of epoch are not changed by other summaries anymore
+
+	signedHash, err := SignedHash(SIGNED_EPOCH_FINISH, epochHandler.Id, "", 0)
+
+	if err != nil {
+		return structures.EpochFinishVoteResponse{Status: "ERROR", Message: "failed to read slashing protection journal"}
+	}
+
+	if signedHash != "" && signedHash != summaryHash {
+		return structures.EpochFinishVoteResponse{Status: "REFUSED", Message: "slashing protection: already signed another summary of epoch"}
+	}
+
+	// Lock creators in the same order to exclude voting for blocks of epoch while we compare stats
+
+	for _, creator := range epochHandler.AnchorsRegistry {
+
+		creatorMutex := globals.BLOCK_CREATORS_MUTEX_REGISTRY.GetMutex(epochHandler.Id, creator)
+
+		creatorMutex.Lock()
+
+		defer creatorMutex.Unlock()
+
+	}
+
+	upgrades := make(map[string]structures.VotingStat)
+
+	for _, creator := range epochHandler.AnchorsRegistry {
+
+		localStat, err := readEpochFinishVotingStat(epochHandler.Id, creator)
+
+		if err != nil {
+			return structures.EpochFinishVoteResponse{Status: "ERROR", Message: "failed to read voting stats"}
+		}
+
+		proposedStat := summary.VotingStats[creator]
+
+		switch {
+
+		case proposedStat.Index < localStat.Index:
+
+			upgrades[creator] = localStat
+
+		case proposedStat.Index == localStat.Index:
+
+			if !strings.EqualFold(proposedStat.Hash, localStat.Hash) {
+				return structures.EpochFinishVoteResponse{Status: "ERROR", Message: "hash mismatch for " + creator}
+			}
+
+		default:
+
+			if err := VerifyEpochFinishVotingStat(epochHandler, creator, &proposedStat); err != nil {
+				return structures.EpochFinishVoteResponse{Status: "ERROR", Message: creator + ": " + err.Error()}
+			}
+
+			if err := StoreVotingStat(epochHandler.Id, creator, proposedStat); err != nil {
+				return structures.EpochFinishVoteResponse{Status: "ERROR", Message: "failed to persist voting stat"}
+			}
+
+		}
+
+	}
+
+	if len(upgrades) > 0 {
+		return structures.EpochFinishVoteResponse{Status: "UPGRADE", Message: "network progressed further", VotingStats: upgrades}
+	}
+
+	if err := databases.FINALIZATION_VOTING_STATS.Put([]byte("EPOCH_FINISH:"+strconv.Itoa(epochHandler.Id)), []byte("TRUE")); err != nil {
+		return structures.EpochFinishVoteResponse{Status: "ERROR", Message: "failed to mark epoch as finished"}
+	}
+
+	if err := CheckAndRecordSignature(SIGNED_EPOCH_FINISH, epochHandler.Id, "", 0, summaryHash); err != nil {
+		if errors.Is(err, ErrSlashableSignature) {
+			return structures.EpochFinishVoteResponse{Status: "REFUSED", Message: "slashing protection: " + err.Error()}
+		}
+
+		return structures.EpochFinishVoteResponse{Status: "ERROR", Message: err.Error()}
+	}
+
+	return structures.EpochFinishVoteResponse{
+		Status:    "OK",
+		Signature: cryptography.GenerateSignature(globals.CONFIGURATION.PrivateKey, dataThatShouldBeSigned),
+	}
+
+}
+
+func VerifyAggregatedEpochFinishProof(proof *structures.AggregatedEpochFinishProof, epochHandler *structures.EpochDataHandler) error {
+
+	if err := validateEpochFinishSummaryShape(&proof.Summary, epochHandler); err != nil {
+		return err
+	}
+
+	epochFullID := epochHandler.Hash + "#" + strconv.Itoa(epochHandler.Id)
+
+	dataThatShouldBeSigned := GetEpochFinishSigningData(&proof.Summary, epochFullID)
+
+	validSignatures := make(map[string]string)
+
+	for signer, signature := range proof.Signatures {
+		if slices.Contains(epochHandler.Quorum, signer) && cryptography.VerifySignature(dataThatShouldBeSigned, signer, signature) {
+			validSignatures[signer] = signature
+		}
+	}
+
+	quorumWeights := GetQuorumWeights(epochHandler)
+
+	if GetSignaturesWeight(quorumWeights, validSignatures) < GetQuorumMajorityByWeights(quorumWeights) {
+		return errors.New("not enough valid signatures")
+	}
+
+	return nil
+
+}
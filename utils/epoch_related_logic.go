@@ -1,25 +1,155 @@
 package utils
 
 import (
+	"encoding/binary"
+	"sort"
+	"strconv"
+
+	"github.com/modulrcloud/modulr-anchors-core/globals"
 	"github.com/modulrcloud/modulr-anchors-core/structures"
+
+	"lukechampine.com/blake3"
 )
 
 type QuorumMemberData struct {
 	PubKey, Url string
 }
 
-func GetQuorumMajority(epochHandler *structures.EpochDataHandler) int {
+// BuildGenesisEpochHandler derives the handler of epoch 0 from genesis and its network parameters. It doesn't read chaindata,
+// so the same handler is derived on start and when snapshot is verified
+func BuildGenesisEpochHandler(params *structures.NetworkParameters) structures.EpochDataHandler {
 
-	quorumSize := len(epochHandler.Quorum)
+	anchorsRegistry := []string{}
 
-	majority := (2 * quorumSize) / 3
+	genesisAnchors := make(map[string]structures.AnchorStorage, len(globals.GENESIS.Anchors))
 
-	majority += 1
+	for _, anchorStorage := range globals.GENESIS.Anchors {
+
+		anchorsRegistry = append(anchorsRegistry, anchorStorage.Pubkey)
+
+		genesisAnchors[anchorStorage.Pubkey] = anchorStorage
 
-	if majority > quorumSize {
-		return quorumSize
 	}
 
+	initEpochHash := Blake3("0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef" + globals.GENESIS.NetworkId)
+
+	epochHandler := structures.EpochDataHandler{
+		Id:              0,
+		Hash:            initEpochHash,
+		AnchorsRegistry: anchorsRegistry,
+		StartTimestamp:  globals.GENESIS.FirstEpochStartTimestamp,
+		Quorum:          []string{}, // will be assigned
+
+		SigningPayloadVersion: params.SigningPayloadVersion,
+	}
+
+	// Assign quorum - pseudorandomly and in deterministic way
+
+	epochHandler.Quorum = GetCurrentEpochQuorum(&epochHandler, params.QuorumSize, initEpochHash)
+
+	epochHandler.QuorumWeights = SnapshotQuorumWeights(epochHandler.Quorum, genesisAnchors, params)
+
+	return epochHandler
+
+}
+
+// GetAnchorVotingWeight returns the current voting weight of the anchor or 0 if anchor is unknown.
+// Majority checks use weights fixed in epoch handler, see GetQuorumWeights
+func GetAnchorVotingWeight(anchorPubkey string) uint64 {
+
+	anchorStorage := GetAnchorFromApprovementThreadState(anchorPubkey)
+
+	if anchorStorage == nil {
+		return 0
+	}
+
+	return anchorStorage.GetVotingWeight()
+
+}
+
+// SnapshotQuorumWeights fixes voting weights of quorum for the epoch being created. Weights set by governance in
+// network parameters have priority. Storages of anchors which join from this epoch are not committed yet, so they are passed in joinedAnchors
+func SnapshotQuorumWeights(quorum []string, joinedAnchors map[string]structures.AnchorStorage, params *structures.NetworkParameters) map[string]uint64 {
+
+	weights := make(map[string]uint64, len(quorum))
+
+	for _, pubKey := range quorum {
+
+		if params != nil && params.AnchorWeights[pubKey] > 0 {
+			weights[pubKey] = params.AnchorWeights[pubKey]
+		} else if joinedAnchor, ok := joinedAnchors[pubKey]; ok {
+			weights[pubKey] = joinedAnchor.GetVotingWeight()
+		} else {
+			weights[pubKey] = GetAnchorVotingWeight(pubKey)
+		}
+
+	}
+
+	return weights
+
+}
+
+// GetQuorumWeights returns the voting weight of each quorum member fixed in epoch handler.
+// Handlers created before weights were fixed use the current weights of anchors
+func GetQuorumWeights(epochHandler *structures.EpochDataHandler) map[string]uint64 {
+
+	if epochHandler.QuorumWeights == nil {
+		return SnapshotQuorumWeights(epochHandler.Quorum, nil, nil)
+	}
+
+	weights := make(map[string]uint64, len(epochHandler.Quorum))
+
+	for _, pubKey := range epochHandler.Quorum {
+
+		weights[pubKey] = epochHandler.QuorumWeights[pubKey]
+
+	}
+
+	return weights
+
+}
+
+func GetQuorumTotalWeight(quorumWeights map[string]uint64) uint64 {
+
+	total := uint64(0)
+
+	for _, weight := range quorumWeights {
+		total += weight
+	}
+
+	return total
+
+}
+
+// GetSignaturesWeight accumulates the weight of quorum members among signers. Signers outside of quorum are ignored
+func GetSignaturesWeight(quorumWeights map[string]uint64, signatures map[string]string) uint64 {
+
+	accumulated := uint64(0)
+
+	for signer := range signatures {
+		accumulated += quorumWeights[signer]
+	}
+
+	return accumulated
+
+}
+
+// GetQuorumMajority returns the accumulated weight (2/3 of total quorum weight + 1) required to reach majority
+func GetQuorumMajority(epochHandler *structures.EpochDataHandler) uint64 {
+
+	return GetQuorumMajorityByWeights(GetQuorumWeights(epochHandler))
+
+}
+
+// GetQuorumMajorityByWeights never returns 0 - quorum with zero total weight (e.g. of unknown anchors) can't reach majority
+func GetQuorumMajorityByWeights(quorumWeights map[string]uint64) uint64 {
+
+	totalWeight := GetQuorumTotalWeight(quorumWeights)
+
+	majority := (2 * totalWeight) / 3
+
+	majority += 1
+
 	return majority
 }
 
@@ -39,12 +169,42 @@ func GetQuorumUrlsAndPubkeys(epochHandler *structures.EpochDataHandler) []Quorum
 
 }
 
+// GetCurrentEpochQuorum deterministically picks quorumSize members of the
+// epoch anchors registry using newEpochSeed (the epoch hash) as the source
+// of randomness. Every node derives the same quorum for the same registry
+// and seed, see docs/quorum_selection.md for the algorithm and test vectors.
 func GetCurrentEpochQuorum(epochHandler *structures.EpochDataHandler, quorumSize int, newEpochSeed string) []string {
 
-	futureQuorum := make([]string, len(epochHandler.AnchorsRegistry))
+	candidates := make([]string, len(epochHandler.AnchorsRegistry))
+
+	copy(candidates, epochHandler.AnchorsRegistry)
+
+	if quorumSize <= 0 || quorumSize >= len(candidates) {
+		return candidates
+	}
+
+	// Canonical order first - the result must not depend on registry ordering
+
+	sort.Strings(candidates)
+
+	// Seeded Fisher-Yates shuffle, then take the first quorumSize members
+
+	for i := len(candidates) - 1; i > 0; i-- {
+
+		j := int(quorumShuffleRandom(newEpochSeed, i) % uint64(i+1))
+
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+
+	}
+
+	return candidates[:quorumSize]
+
+}
+
+func quorumShuffleRandom(seed string, position int) uint64 {
 
-	copy(futureQuorum, epochHandler.AnchorsRegistry)
+	digest := blake3.Sum256([]byte(seed + ":" + strconv.Itoa(position)))
 
-	return futureQuorum
+	return binary.BigEndian.Uint64(digest[:8])
 
 }
@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/modulrcloud/modulr-anchors-core/structures"
+)
+
+// Test vectors of docs/quorum_selection.md
+
+var quorumVectorsRegistry = []string{
+	"9GQ46rqY238rk2neSwgidap9ww5zbAN4dyqyC7j5ZnBK",
+	"6XvZpuCDjdvSuot3eLr24C1wqzcf2w4QqeDh9BnDKsNE",
+	"GUbYLN5NqmRocMBHqS183r2FQRoUjhx1p5nKyyUBpntQ",
+	"3JAeBnsMedzxjCMNWQYcAXtwGVE9A5DBQyXgWBujtL9R",
+	"EGU4u3Anwahbtbx8F1ZZgFQSg2u49EkrkqMERT9r3q1o",
+}
+
+const (
+	quorumVectorsSeed0 = "9815a27e27a9f43edf40ae1eef756e62ed7cdef7471a437f7a2e49c98f6cb280"
+	quorumVectorsSeed1 = "979b700d4efc10b66a8a9662f46bbc3b50cc153ca9953a5a9a872da0200acf82"
+	quorumVectorsSeed2 = "f214d23e644dabf46fabdf78f3de978d2725e8fa58411b7d4bba572a85fe0cf8"
+)
+
+func TestQuorumVectorsSeeds(t *testing.T) {
+
+	genesisHash := Blake3("0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef" + strings.Repeat("a", 64))
+
+	for index, expected := range []string{quorumVectorsSeed0, quorumVectorsSeed1, quorumVectorsSeed2} {
+
+		seed := genesisHash
+
+		for range index {
+			seed = Blake3(seed)
+		}
+
+		if seed != expected {
+			t.Fatalf("seed of epoch %d: expected %s, got %s", index, expected, seed)
+		}
+
+	}
+
+}
+
+func TestGetCurrentEpochQuorumVectors(t *testing.T) {
+
+	vectors := []struct {
+		seed       string
+		quorumSize int
+		expected   []string
+	}{
+		{quorumVectorsSeed0, 1, []string{"GUbYLN5NqmRocMBHqS183r2FQRoUjhx1p5nKyyUBpntQ"}},
+		{quorumVectorsSeed0, 3, []string{"GUbYLN5NqmRocMBHqS183r2FQRoUjhx1p5nKyyUBpntQ", "6XvZpuCDjdvSuot3eLr24C1wqzcf2w4QqeDh9BnDKsNE", "EGU4u3Anwahbtbx8F1ZZgFQSg2u49EkrkqMERT9r3q1o"}},
+		{quorumVectorsSeed1, 1, []string{"GUbYLN5NqmRocMBHqS183r2FQRoUjhx1p5nKyyUBpntQ"}},
+		{quorumVectorsSeed1, 3, []string{"GUbYLN5NqmRocMBHqS183r2FQRoUjhx1p5nKyyUBpntQ", "9GQ46rqY238rk2neSwgidap9ww5zbAN4dyqyC7j5ZnBK", "EGU4u3Anwahbtbx8F1ZZgFQSg2u49EkrkqMERT9r3q1o"}},
+		{quorumVectorsSeed2, 1, []string{"9GQ46rqY238rk2neSwgidap9ww5zbAN4dyqyC7j5ZnBK"}},
+		{quorumVectorsSeed2, 3, []string{"9GQ46rqY238rk2neSwgidap9ww5zbAN4dyqyC7j5ZnBK", "3JAeBnsMedzxjCMNWQYcAXtwGVE9A5DBQyXgWBujtL9R", "GUbYLN5NqmRocMBHqS183r2FQRoUjhx1p5nKyyUBpntQ"}},
+	}
+
+	for _, vector := range vectors {
+
+		epochHandler := &structures.EpochDataHandler{AnchorsRegistry: slices.Clone(quorumVectorsRegistry)}
+
+		quorum := GetCurrentEpochQuorum(epochHandler, vector.quorumSize, vector.seed)
+
+		if !slices.Equal(quorum, vector.expected) {
+			t.Errorf("seed %s, size %d: expected %v, got %v", vector.seed[:8], vector.quorumSize, vector.expected, quorum)
+		}
+
+		// Result doesn't depend on the order of registry
+
+		reversed := slices.Clone(quorumVectorsRegistry)
+
+		slices.Reverse(reversed)
+
+		if quorum := GetCurrentEpochQuorum(&structures.EpochDataHandler{AnchorsRegistry: reversed}, vector.quorumSize, vector.seed); !slices.Equal(quorum, vector.expected) {
+			t.Errorf("seed %s, size %d: reversed registry gives %v", vector.seed[:8], vector.quorumSize, quorum)
+		}
+
+	}
+
+}
+
+func TestGetCurrentEpochQuorumWholeRegistry(t *testing.T) {
+
+	// QUORUM_SIZE <= 0 or >= len(registry) - the whole registry in its order
+
+	for _, quorumSize := range []int{-1, 0, len(quorumVectorsRegistry), len(quorumVectorsRegistry) + 1, 127} {
+
+		epochHandler := &structures.EpochDataHandler{AnchorsRegistry: slices.Clone(quorumVectorsRegistry)}
+
+		quorum := GetCurrentEpochQuorum(epochHandler, quorumSize, quorumVectorsSeed0)
+
+		if !slices.Equal(quorum, quorumVectorsRegistry) {
+			t.Errorf("size %d: expected the whole registry in its order, got %v", quorumSize, quorum)
+		}
+
+		// Quorum is a copy, registry of epoch isn't changed through it
+
+		quorum[0] = "changed"
+
+		if epochHandler.AnchorsRegistry[0] != quorumVectorsRegistry[0] {
+			t.Errorf("size %d: registry was changed through the returned quorum", quorumSize)
+		}
+
+	}
+
+	if quorum := GetCurrentEpochQuorum(&structures.EpochDataHandler{}, 3, quorumVectorsSeed0); len(quorum) != 0 {
+		t.Errorf("empty registry: expected empty quorum, got %v", quorum)
+	}
+
+}
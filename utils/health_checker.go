@@ -3,14 +3,16 @@ package utils
 import (
 	"encoding/json"
 	"strconv"
+	"strings"
 
 	"github.com/modulrcloud/modulr-anchors-core/databases"
 )
 
 // BlockCreatorHealthStatus stores metadata about why we stopped generating proofs for a creator.
 type BlockCreatorHealthStatus struct {
-	Epoch   int    `json:"epoch"`
-	Creator string `json:"creator"`
+	Epoch        int    `json:"epoch"`
+	Creator      string `json:"creator"`
+	StalledIndex int    `json:"stalledIndex"`
 }
 
 func buildBlockCreatorHealthKey(epochID int, creator string) []byte {
@@ -20,11 +22,12 @@ func buildBlockCreatorHealthKey(epochID int, creator string) []byte {
 }
 
 // DisableFinalizationProofsForCreator stores a persistent flag to stop generating proofs for the creator.
-func DisableFinalizationProofsForCreator(epochID int, creator string) error {
+func DisableFinalizationProofsForCreator(epochID int, creator string, stalledIndex int) error {
 
 	status := BlockCreatorHealthStatus{
-		Epoch:   epochID,
-		Creator: creator,
+		Epoch:        epochID,
+		Creator:      creator,
+		StalledIndex: stalledIndex,
 	}
 
 	payload, err := json.Marshal(status)
@@ -33,17 +36,135 @@ func DisableFinalizationProofsForCreator(epochID int, creator string) error {
 		return err
 	}
 
-	return databases.FINALIZATION_VOTING_STATS.Put(buildBlockCreatorHealthKey(epochID, creator), payload, nil)
+	return databases.FINALIZATION_VOTING_STATS.Put(buildBlockCreatorHealthKey(epochID, creator), payload)
 
 }
 
 // IsFinalizationProofsDisabled checks if the creator is banned for the provided epoch.
 func IsFinalizationProofsDisabled(epochID int, creator string) bool {
 
-	if _, err := databases.FINALIZATION_VOTING_STATS.Get(buildBlockCreatorHealthKey(epochID, creator), nil); err == nil {
+	if _, err := databases.FINALIZATION_VOTING_STATS.Get(buildBlockCreatorHealthKey(epochID, creator)); err == nil {
 		return true
 	}
 
 	return false
 
 }
+
+// ReadBlockCreatorHealthStatus returns the stored flag of disabled creator.
+func ReadBlockCreatorHealthStatus(epochID int, creator string) (BlockCreatorHealthStatus, bool) {
+
+	var status BlockCreatorHealthStatus
+
+	raw, err := databases.FINALIZATION_VOTING_STATS.Get(buildBlockCreatorHealthKey(epochID, creator))
+
+	if err != nil || json.Unmarshal(raw, &status) != nil {
+		return status, false
+	}
+
+	return status, true
+
+}
+
+// EnableFinalizationProofsForCreator removes the flag after creator was reinstated by quorum.
+func EnableFinalizationProofsForCreator(epochID int, creator string) error {
+
+	return databases.FINALIZATION_VOTING_STATS.Delete(buildBlockCreatorHealthKey(epochID, creator))
+
+}
+
+// BlockCreatorHealthSnapshot is the last voting stat of creator seen by health checker and the history of its stall.
+type BlockCreatorHealthSnapshot struct {
+	Index               int    `json:"index"`
+	Hash                string `json:"hash"`
+	FirstStallTimestamp uint64 `json:"firstStallTimestamp,omitempty"`
+	StalledObservations int    `json:"stalledObservations"`
+}
+
+const healthSnapshotPrefix = "HEALTH_SNAPSHOT:"
+
+func buildHealthSnapshotKey(epochID int, creator string) []byte {
+
+	return []byte(healthSnapshotPrefix + strconv.Itoa(epochID) + ":" + creator)
+
+}
+
+func StoreHealthSnapshot(epochID int, creator string, snapshot BlockCreatorHealthSnapshot) error {
+
+	payload, err := json.Marshal(snapshot)
+
+	if err != nil {
+		return err
+	}
+
+	return databases.FINALIZATION_VOTING_STATS.Put(buildHealthSnapshotKey(epochID, creator), payload)
+
+}
+
+func DeleteHealthSnapshot(epochID int, creator string) error {
+
+	return databases.FINALIZATION_VOTING_STATS.Delete(buildHealthSnapshotKey(epochID, creator))
+
+}
+
+// LoadHealthSnapshots returns all stored snapshots. Structure is epochID => creator => snapshot
+func LoadHealthSnapshots() (map[int]map[string]BlockCreatorHealthSnapshot, error) {
+
+	iterator := databases.FINALIZATION_VOTING_STATS.NewIterator([]byte(healthSnapshotPrefix))
+
+	defer iterator.Release()
+
+	snapshots := make(map[int]map[string]BlockCreatorHealthSnapshot)
+
+	for iterator.Next() {
+
+		epochAndCreator := strings.SplitN(strings.TrimPrefix(string(iterator.Key()), healthSnapshotPrefix), ":", 2)
+
+		if len(epochAndCreator) != 2 {
+			continue
+		}
+
+		epochID, err := strconv.Atoi(epochAndCreator[0])
+
+		if err != nil {
+			continue
+		}
+
+		var snapshot BlockCreatorHealthSnapshot
+
+		if err := json.Unmarshal(iterator.Value(), &snapshot); err != nil {
+			return nil, err
+		}
+
+		if snapshots[epochID] == nil {
+			snapshots[epochID] = make(map[string]BlockCreatorHealthSnapshot)
+		}
+
+		snapshots[epochID][epochAndCreator[1]] = snapshot
+
+	}
+
+	return snapshots, iterator.Error()
+
+}
+
+// DeleteHealthSnapshotsOfEpoch removes snapshots of epoch which is no longer supported.
+func DeleteHealthSnapshotsOfEpoch(epochID int) error {
+
+	iterator := databases.FINALIZATION_VOTING_STATS.NewIterator([]byte(healthSnapshotPrefix + strconv.Itoa(epochID) + ":"))
+
+	batch := new(databases.Batch)
+
+	for iterator.Next() {
+		batch.Delete(append([]byte{}, iterator.Key()...))
+	}
+
+	iterator.Release()
+
+	if err := iterator.Error(); err != nil {
+		return err
+	}
+
+	return databases.FINALIZATION_VOTING_STATS.Write(batch)
+
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/modulrcloud/modulr-anchors-core/cryptography"
+	"github.com/modulrcloud/modulr-anchors-core/globals"
+)
+
+var HTTP_CLIENT = &http.Client{Timeout: 5 * time.Second}
+
+// Headers of authenticated requests between anchors (see http_pack.AuthenticatedPeer)
+const (
+	PEER_PUBKEY_HEADER    = "X-Anchor-Pubkey"
+	PEER_TIMESTAMP_HEADER = "X-Anchor-Timestamp"
+	PEER_NONCE_HEADER     = "X-Anchor-Nonce"
+	PEER_SIGNATURE_HEADER = "X-Anchor-Signature"
+)
+
+// PostJSON sends the payload to another anchor and returns the response body and status code.
+// Request is signed with our key, so it's accepted by authenticated routes
+func PostJSON(url string, payload []byte) ([]byte, int, error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	signPeerRequest(req, payload)
+	resp, err := HTTP_CLIENT.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, err
+	}
+	return body, resp.StatusCode, nil
+}
+
+// GetJSON reads public route of another anchor and returns the response body and status code
+func GetJSON(url string) ([]byte, int, error) {
+	resp, err := HTTP_CLIENT.Get(url)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, err
+	}
+	return body, resp.StatusCode, nil
+}
+
+func signPeerRequest(req *http.Request, payload []byte) {
+	nonceBytes := make([]byte, 16)
+	rand.Read(nonceBytes)
+	timestamp := strconv.FormatInt(GetUTCTimestampInMilliSeconds(), 10)
+	nonce := hex.EncodeToString(nonceBytes)
+	dataToSign := GetPeerRequestSigningData(req.Method, req.URL.Path, timestamp, nonce, payload)
+	req.Header.Set(PEER_PUBKEY_HEADER, globals.CONFIGURATION.PublicKey)
+	req.Header.Set(PEER_TIMESTAMP_HEADER, timestamp)
+	req.Header.Set(PEER_NONCE_HEADER, nonce)
+	req.Header.Set(PEER_SIGNATURE_HEADER, cryptography.GenerateSignature(globals.CONFIGURATION.PrivateKey, dataToSign))
+}
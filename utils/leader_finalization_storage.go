@@ -7,8 +7,6 @@ import (
 
 	"github.com/modulrcloud/modulr-anchors-core/databases"
 	"github.com/modulrcloud/modulr-anchors-core/structures"
-
-	ldbErrors "github.com/syndtr/goleveldb/leveldb/errors"
 )
 
 func aggregatedLeaderFinalizationProofKey(epochIndex int, leader string) []byte {
@@ -25,7 +23,7 @@ func StoreAggregatedLeaderFinalizationProof(proof structures.AggregatedLeaderFin
 		return err
 	}
 
-	return databases.FINALIZATION_VOTING_STATS.Put(aggregatedLeaderFinalizationProofKey(proof.EpochIndex, proof.Leader), payload, nil)
+	return databases.FINALIZATION_VOTING_STATS.Put(aggregatedLeaderFinalizationProofKey(proof.EpochIndex, proof.Leader), payload)
 
 }
 
@@ -33,10 +31,10 @@ func LoadAggregatedLeaderFinalizationProof(epochIndex int, leader string) (struc
 
 	var proof structures.AggregatedLeaderFinalizationProof
 
-	raw, err := databases.FINALIZATION_VOTING_STATS.Get(aggregatedLeaderFinalizationProofKey(epochIndex, leader), nil)
+	raw, err := databases.FINALIZATION_VOTING_STATS.Get(aggregatedLeaderFinalizationProofKey(epochIndex, leader))
 
 	if err != nil {
-		if errors.Is(err, ldbErrors.ErrNotFound) {
+		if errors.Is(err, databases.ErrNotFound) {
 			return proof, nil
 		}
 		return proof, err
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/modulrcloud/modulr-anchors-core/cryptography"
+	"github.com/modulrcloud/modulr-anchors-core/globals"
+	"github.com/modulrcloud/modulr-anchors-core/structures"
+
+	"github.com/btcsuite/btcutil/base58"
+)
+
+// GetModulrCoreQuorumWeights returns members of modulr-core quorum from genesis. Each member votes with weight 1,
+// members with malformed pubkeys are skipped
+func GetModulrCoreQuorumWeights() map[string]uint64 {
+
+	weights := make(map[string]uint64, len(globals.GENESIS.ModulrCoreQuorum))
+
+	for _, member := range globals.GENESIS.ModulrCoreQuorum {
+		if len(base58.Decode(member)) == 32 {
+			weights[member] = 1
+		}
+	}
+
+	return weights
+
+}
+
+// VerifyAggregatedLeaderFinalizationProof checks that majority of modulr-core quorum signed the leader finalization
+// for the epoch of proof. epochHandler should be the handler of proof.EpochIndex
+func VerifyAggregatedLeaderFinalizationProof(proof *structures.AggregatedLeaderFinalizationProof, epochHandler *structures.EpochDataHandler) error {
+
+	if proof.EpochIndex != epochHandler.Id {
+		return fmt.Errorf("epoch %d mismatch", proof.EpochIndex)
+	}
+
+	if proof.Leader == "" {
+		return errors.New("missing leader")
+	}
+
+	if proof.VotingStat.Index < 0 || proof.VotingStat.Hash == "" {
+		return errors.New("invalid voting stat")
+	}
+
+	if len(proof.Signatures) == 0 {
+		return errors.New("missing signatures")
+	}
+
+	quorumWeights := GetModulrCoreQuorumWeights()
+
+	if len(quorumWeights) == 0 {
+		return errors.New("modulr-core quorum is not configured")
+	}
+
+	epochFullID := epochHandler.Hash + "#" + strconv.Itoa(epochHandler.Id)
+
+	dataThatShouldBeSigned := GetLeaderFinalizationProofSigningData(epochHandler.SigningPayloadVersion, proof.Leader, &proof.VotingStat, epochFullID)
+
+	verifiedWeight := uint64(0)
+
+	for signer, signature := range proof.Signatures {
+
+		weight, inQuorum := quorumWeights[signer]
+
+		if !inQuorum {
+			return fmt.Errorf("signer %s is not in modulr-core quorum", signer)
+		}
+
+		if !cryptography.VerifySignature(dataThatShouldBeSigned, signer, signature) {
+			return fmt.Errorf("invalid signature of %s", signer)
+		}
+
+		verifiedWeight += weight
+
+	}
+
+	if majority := GetQuorumMajorityByWeights(quorumWeights); verifiedWeight < majority {
+		return fmt.Errorf("verified signatures weight %d < %d", verifiedWeight, majority)
+	}
+
+	return nil
+
+}
@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/modulrcloud/modulr-anchors-core/cryptography"
+	"github.com/modulrcloud/modulr-anchors-core/databases"
+	"github.com/modulrcloud/modulr-anchors-core/structures"
+)
+
+func networkParametersProofKeyPrefix(epochIndex int) []byte {
+	return []byte("NETWORK_PARAMETERS_PROOF:" + strconv.Itoa(epochIndex) + ":")
+}
+
+// Hashes of proposals which operator of our node allowed to vote for. Kept only in memory - approvals are lost on restart
+var approvedNetworkParametersProposals sync.Map
+
+// GetNetworkParametersProposalHash returns hash used by operators to approve proposals
+func GetNetworkParametersProposalHash(proposal *structures.NetworkParametersProposal) string {
+	return Blake3(GetNetworkParametersProposalSigningData(proposal))
+}
+
+func ApproveNetworkParametersProposal(proposalHash string) {
+	approvedNetworkParametersProposals.Store(proposalHash, true)
+}
+
+func IsNetworkParametersProposalApproved(proposalHash string) bool {
+	_, approved := approvedNetworkParametersProposals.Load(proposalHash)
+	return approved
+}
+
+// ValidateNetworkParameters rejects parameters which would stop the network
+func ValidateNetworkParameters(params *structures.NetworkParameters) error {
+
+	switch {
+	case params.QuorumSize <= 0:
+		return errors.New("quorum size should be positive")
+	case params.EpochDuration <= 0:
+		return errors.New("epoch duration should be positive")
+	case params.BlockTime <= 0:
+		return errors.New("block time should be positive")
+	case params.MaxBlockSizeInBytes <= 0:
+		return errors.New("max block size should be positive")
+	case params.TxLimitPerBlock <= 0:
+		return errors.New("txs limit per block should be positive")
+	case params.MaxEpochsToSupport < 2:
+		return errors.New("max epochs to support should be at least 2, epoch finish proof of the previous epoch is required for rotation")
+	case params.BlockCreatorsHealthCheckIntervalMs <= 0:
+		return errors.New("health check interval should be positive")
+	case !IsSupportedSigningPayloadVersion(params.SigningPayloadVersion):
+		return errors.New("unsupported signing payload version")
+	case params.StallDetectionPolicy.StalledObservationsToDisable < 0:
+		return errors.New("stalled observations to disable should not be negative")
+	case params.StallDetectionPolicy.EpochStartGracePeriodMs < 0:
+		return errors.New("epoch start grace period should not be negative")
+	case params.StallDetectionPolicy.LaggingNodeThresholdPercent < 0 || params.StallDetectionPolicy.LaggingNodeThresholdPercent > 100:
+		return errors.New("lagging node threshold should be in range 0-100")
+	}
+
+	for anchor, weight := range params.AnchorWeights {
+		if weight == 0 {
+			return fmt.Errorf("weight of anchor %s should be positive", anchor)
+		}
+	}
+
+	return nil
+
+}
+
+// ValidateNetworkParametersProposal checks that proposal was made and signed by registered anchor for the epoch and contains sane parameters
+func ValidateNetworkParametersProposal(proposal *structures.NetworkParametersProposal, epochHandler *structures.EpochDataHandler) error {
+
+	if proposal.EpochIndex != epochHandler.Id {
+		return fmt.Errorf("proposal epoch %d mismatch", proposal.EpochIndex)
+	}
+
+	if !slices.Contains(epochHandler.AnchorsRegistry, proposal.Proposer) {
+		return fmt.Errorf("proposer %s is not part of epoch %d", proposal.Proposer, proposal.EpochIndex)
+	}
+
+	if !cryptography.VerifySignature(GetNetworkParametersProposalSigningData(proposal), proposal.Proposer, proposal.ProposerSignature) {
+		return errors.New("invalid proposer signature")
+	}
+
+	return ValidateNetworkParameters(&proposal.Parameters)
+
+}
+
+func VerifyAggregatedNetworkParametersProof(proof *structures.AggregatedNetworkParametersProof, epochHandler *structures.EpochDataHandler) error {
+
+	if err := ValidateNetworkParametersProposal(&proof.Proposal, epochHandler); err != nil {
+		return err
+	}
+
+	dataToVerify := GetNetworkParametersProposalSigningData(&proof.Proposal)
+
+	quorumWeights := GetQuorumWeights(epochHandler)
+
+	verifiedWeight := uint64(0)
+
+	for voter, signature := range proof.Signatures {
+
+		weight, inQuorum := quorumWeights[voter]
+
+		if inQuorum && cryptography.VerifySignature(dataToVerify, voter, signature) {
+			verifiedWeight += weight
+		}
+
+	}
+
+	if majority := GetQuorumMajorityByWeights(quorumWeights); verifiedWeight < majority {
+		return fmt.Errorf("verified signatures weight %d < %d", verifiedWeight, majority)
+	}
+
+	return nil
+
+}
+
+// StoreFinalizedNetworkParametersProofs records proofs from the block which received AFP (local view of node).
+// Parameters are changed by proofs from blocks of the epoch finish proof, see block_pack.CollectEpochChanges
+func StoreFinalizedNetworkParametersProofs(epochIndex int, proofs []structures.AggregatedNetworkParametersProof, batch *databases.AtomicBatch) error {
+
+	for _, proof := range proofs {
+
+		if proof.Proposal.EpochIndex != epochIndex {
+			continue
+		}
+
+		payload, err := json.Marshal(proof)
+
+		if err != nil {
+			return err
+		}
+
+		key := append(networkParametersProofKeyPrefix(epochIndex), []byte(proof.Proposal.Proposer)...)
+
+		batch.Put(databases.EPOCH_DATA, key, payload)
+
+	}
+
+	return nil
+
+}
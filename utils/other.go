@@ -2,6 +2,7 @@ package utils
 
 import (
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strconv"
@@ -92,11 +93,19 @@ func EpochStillFresh(epochHandler *structures.EpochDataHandler, networkParams *s
 
 }
 
+// EpochIsOver reports whether time of epoch passed - it was already rotated or it's the latest one, but not fresh anymore.
+// Caller should hold APPROVEMENT_THREAD_METADATA lock or pass a copy of handler
+func EpochIsOver(epochHandler *structures.EpochDataHandler, handler *structures.ApprovementThreadMetadataHandler) bool {
+
+	return epochHandler.Id < handler.GetEpochHandler().Id || !EpochStillFresh(epochHandler, &handler.NetworkParameters)
+
+}
+
 func SignalAboutEpochRotationExists(epochIndex int) bool {
 
 	keyValue := []byte("EPOCH_FINISH:" + strconv.Itoa(epochIndex))
 
-	if readyToChangeEpochRaw, err := databases.FINALIZATION_VOTING_STATS.Get(keyValue, nil); err == nil && string(readyToChangeEpochRaw) == "TRUE" {
+	if readyToChangeEpochRaw, err := databases.FINALIZATION_VOTING_STATS.Get(keyValue); err == nil && string(readyToChangeEpochRaw) == "TRUE" {
 
 		return true
 
@@ -106,17 +115,47 @@ func SignalAboutEpochRotationExists(epochIndex int) bool {
 
 }
 
+// GetEpochHandlerByID returns handler of supported epoch or nil
 func GetEpochHandlerByID(id int) *structures.EpochDataHandler {
 
 	handlers.APPROVEMENT_THREAD_METADATA.RWMutex.RLock()
 
 	defer handlers.APPROVEMENT_THREAD_METADATA.RWMutex.RUnlock()
 
+	return GetEpochHandlerByIDUnlocked(id)
+
+}
+
+// GetEpochHandlerByIDUnlocked is GetEpochHandlerByID for callers which already hold APPROVEMENT_THREAD_METADATA lock
+func GetEpochHandlerByIDUnlocked(id int) *structures.EpochDataHandler {
+
 	epochHandlers := handlers.APPROVEMENT_THREAD_METADATA.Handler.GetEpochHandlers()
+
 	for idx := range epochHandlers {
 		if epochHandlers[idx].Id == id {
 			return &epochHandlers[idx]
 		}
 	}
+
 	return nil
+
+}
+
+// LoadEpochHandler returns handler of epoch stored on rotation. Stored handlers are never pruned
+func LoadEpochHandler(id int) (*structures.EpochDataHandler, error) {
+
+	rawEpochHandler, err := databases.EPOCH_DATA.Get([]byte("EPOCH_HANDLER:" + strconv.Itoa(id)))
+
+	if err != nil {
+		return nil, err
+	}
+
+	var epochHandler structures.EpochDataHandler
+
+	if err := json.Unmarshal(rawEpochHandler, &epochHandler); err != nil {
+		return nil, err
+	}
+
+	return &epochHandler, nil
+
 }
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"strconv"
+
+	"github.com/modulrcloud/modulr-anchors-core/databases"
+	"github.com/modulrcloud/modulr-anchors-core/structures"
+)
+
+// Inclusion index stores which AARPs and ALFPs (by epoch, subject and index) were already included to our finalized blocks
+
+func proofInclusionKey(proofType string, epochIndex int, subject string, index int) []byte {
+	return []byte("INCLUDED:" + proofType + ":" + strconv.Itoa(epochIndex) + ":" + subject + ":" + strconv.Itoa(index))
+}
+
+func IsAggregatedAnchorRotationProofIncluded(proof *structures.AggregatedAnchorRotationProof) bool {
+	has, err := databases.EPOCH_DATA.Has(proofInclusionKey("AARP", proof.EpochIndex, proof.Anchor, proof.VotingStat.Index))
+	return err == nil && has
+}
+
+func IsAggregatedLeaderFinalizationProofIncluded(proof *structures.AggregatedLeaderFinalizationProof) bool {
+	has, err := databases.EPOCH_DATA.Has(proofInclusionKey("ALFP", proof.EpochIndex, proof.Leader, proof.VotingStat.Index))
+	return err == nil && has
+}
+
+// StoreProofInclusions marks proofs of our finalized block as included
+func StoreProofInclusions(rotationProofs []structures.AggregatedAnchorRotationProof, leaderFinalizationProofs []structures.AggregatedLeaderFinalizationProof, batch *databases.AtomicBatch) {
+
+	for _, proof := range rotationProofs {
+		batch.Put(databases.EPOCH_DATA, proofInclusionKey("AARP", proof.EpochIndex, proof.Anchor, proof.VotingStat.Index), []byte("TRUE"))
+	}
+
+	for _, proof := range leaderFinalizationProofs {
+		batch.Put(databases.EPOCH_DATA, proofInclusionKey("ALFP", proof.EpochIndex, proof.Leader, proof.VotingStat.Index), []byte("TRUE"))
+	}
+
+}
@@ -8,22 +8,31 @@ import (
 	"github.com/modulrcloud/modulr-anchors-core/structures"
 )
 
+// VerifyAggregatedFinalizationProof has no side effects. Votes of accepted AFP are indexed by caller with RecordAggregatedFinalizationProofVotes
 func VerifyAggregatedFinalizationProof(proof *structures.AggregatedFinalizationProof, epochHandler *structures.EpochDataHandler) bool {
 
 	epochFullID := epochHandler.Hash + "#" + strconv.Itoa(epochHandler.Id)
 
-	dataThatShouldBeSigned := strings.Join([]string{proof.PrevBlockHash, proof.BlockId, proof.BlockHash, epochFullID}, ":")
+	dataThatShouldBeSigned := GetFinalizationProofSigningData(epochHandler.SigningPayloadVersion, proof.PrevBlockHash, proof.BlockId, proof.BlockHash, epochFullID)
 
-	majority := GetQuorumMajority(epochHandler)
+	quorumWeights := GetQuorumWeights(epochHandler)
 
-	okSignatures := 0
+	// AFP in BLS format contains single aggregated signature instead of per voter signatures
+
+	if proof.AggregatedSignature != "" {
+		return verifyBlsAggregatedFinalizationProof(proof, dataThatShouldBeSigned, epochHandler, quorumWeights)
+	}
+
+	majority := GetQuorumMajorityByWeights(quorumWeights)
+
+	accumulatedWeight := uint64(0)
 
 	seen := make(map[string]bool)
 
-	quorumMap := make(map[string]bool)
+	quorumMap := make(map[string]uint64)
 
-	for _, pk := range epochHandler.Quorum {
-		quorumMap[strings.ToLower(pk)] = true
+	for pk, weight := range quorumWeights {
+		quorumMap[strings.ToLower(pk)] = weight
 	}
 
 	for pubKey, signature := range proof.Proofs {
@@ -32,12 +41,13 @@ func VerifyAggregatedFinalizationProof(proof *structures.AggregatedFinalizationP
 
 			loweredPubKey := strings.ToLower(pubKey)
 
-			if quorumMap[loweredPubKey] && !seen[loweredPubKey] {
+			if weight, inQuorum := quorumMap[loweredPubKey]; inQuorum && !seen[loweredPubKey] {
 				seen[loweredPubKey] = true
-				okSignatures++
+				accumulatedWeight += weight
 			}
+
 		}
 	}
 
-	return okSignatures >= majority
+	return accumulatedWeight >= majority
 }
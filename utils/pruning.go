@@ -0,0 +1,224 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/modulrcloud/modulr-anchors-core/databases"
+)
+
+// Key in APPROVEMENT_THREAD_METADATA with index of the last fully pruned epoch
+var prunedEpochKey = []byte("PRUNED_EPOCH")
+
+type epochKeys struct {
+	store  databases.KVStore
+	prefix string
+}
+
+// epochKeyPrefixes lists prefixes of all the per-epoch data (see docs/chaindata_schema.md). Each prefix ends with ":",
+// so epoch 1 never matches keys of epoch 10
+func epochKeyPrefixes(epochIndex int) []epochKeys {
+
+	epoch := strconv.Itoa(epochIndex) + ":"
+
+	return []epochKeys{
+		{databases.BLOCKS, epoch},
+		{databases.BLOCKS, "IN_FLIGHT:" + epoch},
+		{databases.EPOCH_DATA, "AFP:" + epoch},
+		{databases.EPOCH_DATA, "ANCHOR_MEMBERSHIP:" + epoch},
+		{databases.EPOCH_DATA, "NETWORK_PARAMETERS_PROOF:" + epoch},
+		{databases.EPOCH_DATA, "BLOCK_EQUIVOCATION:" + epoch},
+		{databases.EPOCH_DATA, "DOUBLE_VOTE:" + epoch},
+		{databases.EPOCH_DATA, "INCLUDED:AARP:" + epoch},
+		{databases.EPOCH_DATA, "INCLUDED:ALFP:" + epoch},
+		{databases.FINALIZATION_VOTING_STATS, epoch},
+		{databases.FINALIZATION_VOTING_STATS, "AARP:" + epoch},
+		{databases.FINALIZATION_VOTING_STATS, "ALFP:" + epoch},
+		{databases.FINALIZATION_VOTING_STATS, "BLOCK_CREATOR_HEALTH:" + epoch},
+		{databases.FINALIZATION_VOTING_STATS, "HEALTH_SNAPSHOT:" + epoch},
+		{databases.FINALIZATION_VOTING_STATS, "CREATOR_REINSTATEMENT:" + epoch},
+	}
+
+}
+
+// epochKeysWithoutSuffix are single keys which end with epoch index. Epoch handlers are never pruned - snapshot import
+// verifies the chain of handlers from genesis
+func epochKeysWithoutSuffix(epochIndex int) []epochKeys {
+
+	epoch := strconv.Itoa(epochIndex)
+
+	return []epochKeys{
+		{databases.EPOCH_DATA, "EPOCH_FINISH_PROOF:" + epoch},
+		{databases.EPOCH_DATA, "EPOCH_CHANGES:" + epoch},
+		{databases.FINALIZATION_VOTING_STATS, "EPOCH_FINISH:" + epoch},
+	}
+
+}
+
+// GetPrunedEpoch returns index of the last fully pruned epoch or -1
+func GetPrunedEpoch() int {
+
+	raw, err := databases.APPROVEMENT_THREAD_METADATA.Get(prunedEpochKey)
+
+	if err != nil {
+		return -1
+	}
+
+	prunedEpoch, err := strconv.Atoi(string(raw))
+
+	if err != nil {
+		return -1
+	}
+
+	return prunedEpoch
+
+}
+
+// SetPrunedEpoch marks epochs up to epochIndex as pruned, -1 removes the mark
+func SetPrunedEpoch(epochIndex int) error {
+
+	if epochIndex < 0 {
+		return databases.APPROVEMENT_THREAD_METADATA.Delete(prunedEpochKey)
+	}
+
+	return databases.APPROVEMENT_THREAD_METADATA.Put(prunedEpochKey, []byte(strconv.Itoa(epochIndex)))
+
+}
+
+// epochPruner deletes keys in batches of batchSize with pause between them, so pruning doesn't compete with the node for disk
+type epochPruner struct {
+	batch     *databases.AtomicBatch
+	batchSize int
+	interval  time.Duration
+	deleted   int
+}
+
+func (pruner *epochPruner) delete(store databases.KVStore, key []byte) error {
+
+	pruner.batch.Delete(store, key)
+
+	pruner.deleted++
+
+	if pruner.batch.Len() < pruner.batchSize {
+		return nil
+	}
+
+	if err := pruner.batch.Write(); err != nil {
+		return err
+	}
+
+	pruner.batch = databases.NewAtomicBatch()
+
+	time.Sleep(pruner.interval)
+
+	return nil
+
+}
+
+// PruneEpoch deletes data of epoch. With keepProofs the AFP with the biggest index of each creator is kept.
+// Epoch is marked as pruned in the last batch, so interrupted pruning is repeated from the start of epoch
+func PruneEpoch(epochIndex int, keepProofs bool, batchSize int, interval time.Duration) (int, error) {
+
+	pruner := &epochPruner{batch: databases.NewAtomicBatch(), batchSize: batchSize, interval: interval}
+
+	for _, keys := range epochKeyPrefixes(epochIndex) {
+
+		var finalAfps map[string]string
+
+		if keepProofs && keys.store == databases.EPOCH_DATA && strings.HasPrefix(keys.prefix, "AFP:") {
+			finalAfps = findFinalAfps(keys.prefix)
+		}
+
+		if err := pruner.deleteByPrefix(keys, finalAfps); err != nil {
+			return pruner.deleted, err
+		}
+
+	}
+
+	for _, key := range epochKeysWithoutSuffix(epochIndex) {
+
+		if err := pruner.delete(key.store, []byte(key.prefix)); err != nil {
+			return pruner.deleted, err
+		}
+
+	}
+
+	pruner.batch.Put(databases.APPROVEMENT_THREAD_METADATA, prunedEpochKey, []byte(strconv.Itoa(epochIndex)))
+
+	return pruner.deleted, pruner.batch.Write()
+
+}
+
+func (pruner *epochPruner) deleteByPrefix(keys epochKeys, keep map[string]string) error {
+
+	// Iterator works over the state on its creation, so deletes of the same prefix don't affect it
+
+	iterator := keys.store.NewIterator([]byte(keys.prefix))
+
+	defer iterator.Release()
+
+	for iterator.Next() {
+
+		key := string(iterator.Key())
+
+		if creator, index, ok := splitCreatorAndIndex(strings.TrimPrefix(key, keys.prefix)); ok && keep != nil && keep[creator] == index {
+			continue
+		}
+
+		if err := pruner.delete(keys.store, []byte(key)); err != nil {
+			return err
+		}
+
+	}
+
+	return iterator.Error()
+
+}
+
+// findFinalAfps returns creator => biggest block index with AFP
+func findFinalAfps(prefix string) map[string]string {
+
+	finalIndexes := make(map[string]int)
+
+	iterator := databases.EPOCH_DATA.NewIterator([]byte(prefix))
+
+	defer iterator.Release()
+
+	for iterator.Next() {
+
+		creator, index, ok := splitCreatorAndIndex(strings.TrimPrefix(string(iterator.Key()), prefix))
+
+		if !ok {
+			continue
+		}
+
+		if blockIndex, err := strconv.Atoi(index); err == nil {
+			if current, exists := finalIndexes[creator]; !exists || blockIndex > current {
+				finalIndexes[creator] = blockIndex
+			}
+		}
+
+	}
+
+	finalAfps := make(map[string]string, len(finalIndexes))
+
+	for creator, index := range finalIndexes {
+		finalAfps[creator] = strconv.Itoa(index)
+	}
+
+	return finalAfps
+
+}
+
+func splitCreatorAndIndex(suffix string) (string, string, bool) {
+
+	separator := strings.LastIndex(suffix, ":")
+
+	if separator < 0 {
+		return "", "", false
+	}
+
+	return suffix[:separator], suffix[separator+1:], true
+
+}
@@ -0,0 +1,35 @@
+package utils
+
+import "testing"
+
+func TestGetQuorumMajorityByWeights(t *testing.T) {
+
+	cases := []struct {
+		weights  map[string]uint64
+		majority uint64
+	}{
+		{map[string]uint64{"a": 1}, 1},
+		{map[string]uint64{"a": 1, "b": 1, "c": 1}, 3},
+		{map[string]uint64{"a": 1, "b": 1, "c": 1, "d": 1}, 3},
+		{map[string]uint64{"a": 3, "b": 1, "c": 1}, 4},
+		{map[string]uint64{}, 1},
+		{map[string]uint64{"a": 0, "b": 0}, 1},
+	}
+
+	for _, c := range cases {
+
+		if majority := GetQuorumMajorityByWeights(c.weights); majority != c.majority {
+			t.Errorf("weights %v: expected majority %d, got %d", c.weights, c.majority, majority)
+		}
+
+	}
+
+	// Quorum with zero total weight never reaches majority
+
+	zeroWeights := map[string]uint64{"a": 0, "b": 0}
+
+	if GetSignaturesWeight(zeroWeights, map[string]string{"a": "sig", "b": "sig"}) >= GetQuorumMajorityByWeights(zeroWeights) {
+		t.Error("signatures of zero weight quorum reached majority")
+	}
+
+}
@@ -0,0 +1,217 @@
+package utils
+
+import (
+	"net/netip"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/modulrcloud/modulr-anchors-core/structures"
+)
+
+// Buckets which were not used for this period are removed
+const idleTokenBucketTtl = 10 * time.Minute
+
+type tokenBucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// RateLimiter keeps token bucket per key (e.g. IP or IP:route)
+type RateLimiter struct {
+	sync.Mutex
+	config      structures.TokenBucketConfig
+	buckets     map[string]*tokenBucket
+	lastCleanup time.Time
+}
+
+func NewRateLimiter(config structures.TokenBucketConfig) *RateLimiter {
+
+	if config.Burst < 1 {
+		config.Burst = 1
+	}
+
+	return &RateLimiter{config: config, buckets: make(map[string]*tokenBucket), lastCleanup: time.Now()}
+
+}
+
+// Allow takes a token of key. Limiter with zero rate allows everything
+func (limiter *RateLimiter) Allow(key string) bool {
+
+	if limiter == nil || limiter.config.Rate <= 0 {
+		return true
+	}
+
+	limiter.Lock()
+	defer limiter.Unlock()
+
+	now := time.Now()
+
+	if now.Sub(limiter.lastCleanup) > idleTokenBucketTtl {
+		for bucketKey, bucket := range limiter.buckets {
+			if now.Sub(bucket.lastSeen) > idleTokenBucketTtl {
+				delete(limiter.buckets, bucketKey)
+			}
+		}
+		limiter.lastCleanup = now
+	}
+
+	bucket, ok := limiter.buckets[key]
+
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(limiter.config.Burst), lastSeen: now}
+		limiter.buckets[key] = bucket
+	}
+
+	bucket.tokens += now.Sub(bucket.lastSeen).Seconds() * limiter.config.Rate
+
+	if bucket.tokens > float64(limiter.config.Burst) {
+		bucket.tokens = float64(limiter.config.Burst)
+	}
+
+	bucket.lastSeen = now
+
+	if bucket.tokens < 1 {
+		return false
+	}
+
+	bucket.tokens--
+
+	return true
+
+}
+
+// KeyedRateLimiters holds separate limiter for each route or message type
+type KeyedRateLimiters struct {
+	exact    map[string]*RateLimiter
+	prefixes map[string]*RateLimiter
+}
+
+// NewKeyedRateLimiters creates limiters from config. Keys ending with "/" match all routes with such prefix
+func NewKeyedRateLimiters(configs map[string]structures.TokenBucketConfig) *KeyedRateLimiters {
+
+	limiters := &KeyedRateLimiters{exact: make(map[string]*RateLimiter), prefixes: make(map[string]*RateLimiter)}
+
+	for key, config := range configs {
+		if strings.HasSuffix(key, "/") {
+			limiters.prefixes[key] = NewRateLimiter(config)
+		} else {
+			limiters.exact[key] = NewRateLimiter(config)
+		}
+	}
+
+	return limiters
+
+}
+
+// Allow takes a token of client for route. Routes without configured limit are allowed
+func (limiters *KeyedRateLimiters) Allow(route, client string) bool {
+
+	if limiter, ok := limiters.exact[route]; ok {
+		return limiter.Allow(client)
+	}
+
+	longestPrefix := ""
+
+	for prefix := range limiters.prefixes {
+		if strings.HasPrefix(route, prefix) && len(prefix) > len(longestPrefix) {
+			longestPrefix = prefix
+		}
+	}
+
+	if longestPrefix == "" {
+		return true
+	}
+
+	return limiters.prefixes[longestPrefix].Allow(client)
+
+}
+
+// ConnectionsLimiter bounds the number of concurrent connections per IP. Zero max disables the limit
+type ConnectionsLimiter struct {
+	sync.Mutex
+	max    int
+	active map[string]int
+}
+
+func NewConnectionsLimiter(max int) *ConnectionsLimiter {
+
+	return &ConnectionsLimiter{max: max, active: make(map[string]int)}
+
+}
+
+func (limiter *ConnectionsLimiter) Acquire(ip string) bool {
+
+	limiter.Lock()
+	defer limiter.Unlock()
+
+	if limiter.max > 0 && limiter.active[ip] >= limiter.max {
+		return false
+	}
+
+	limiter.active[ip]++
+
+	return true
+
+}
+
+func (limiter *ConnectionsLimiter) Release(ip string) {
+
+	limiter.Lock()
+	defer limiter.Unlock()
+
+	if limiter.active[ip] <= 1 {
+		delete(limiter.active, ip)
+		return
+	}
+
+	limiter.active[ip]--
+
+}
+
+// RateLimitsMetrics counts requests, messages and connections rejected by limits
+type RateLimitsMetrics struct {
+	HttpRateLimited         atomic.Uint64
+	HttpBodyTooLarge        atomic.Uint64
+	HttpConnectionsRejected atomic.Uint64
+	WsRateLimited           atomic.Uint64
+	WsMessageTooLarge       atomic.Uint64
+	WsConnectionsRejected   atomic.Uint64
+}
+
+var RATE_LIMITS_METRICS RateLimitsMetrics
+
+// RateLimitsCounters is a snapshot of RateLimitsMetrics
+type RateLimitsCounters struct {
+	HttpRateLimited         uint64 `json:"httpRateLimited"`
+	HttpBodyTooLarge        uint64 `json:"httpBodyTooLarge"`
+	HttpConnectionsRejected uint64 `json:"httpConnectionsRejected"`
+	WsRateLimited           uint64 `json:"wsRateLimited"`
+	WsMessageTooLarge       uint64 `json:"wsMessageTooLarge"`
+	WsConnectionsRejected   uint64 `json:"wsConnectionsRejected"`
+}
+
+func (metrics *RateLimitsMetrics) Counters() RateLimitsCounters {
+
+	return RateLimitsCounters{
+		HttpRateLimited:         metrics.HttpRateLimited.Load(),
+		HttpBodyTooLarge:        metrics.HttpBodyTooLarge.Load(),
+		HttpConnectionsRejected: metrics.HttpConnectionsRejected.Load(),
+		WsRateLimited:           metrics.WsRateLimited.Load(),
+		WsMessageTooLarge:       metrics.WsMessageTooLarge.Load(),
+		WsConnectionsRejected:   metrics.WsConnectionsRejected.Load(),
+	}
+
+}
+
+// IpFromAddress returns IP of host:port address, so all connections of the same host share limits
+func IpFromAddress(address string) string {
+
+	if addrPort, err := netip.ParseAddrPort(address); err == nil {
+		return addrPort.Addr().Unmap().String()
+	}
+
+	return address
+
+}
@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/modulrcloud/modulr-anchors-core/globals"
+	"github.com/modulrcloud/modulr-anchors-core/structures"
+)
+
+// Versions of signing payloads. Version is set per epoch (see EpochDataHandler.SigningPayloadVersion)
+// from network parameters, so a running network switches to a new version at epoch boundary
+const (
+	SIGNING_PAYLOAD_VERSION_LEGACY           = 0 // plain prevHash:blockId:blockHash:epochFullID for all proofs
+	SIGNING_PAYLOAD_VERSION_DOMAIN_SEPARATED = 1 // every message kind has own domain tag
+	LATEST_SIGNING_PAYLOAD_VERSION           = SIGNING_PAYLOAD_VERSION_DOMAIN_SEPARATED
+)
+
+const (
+	SIGNING_DOMAIN_BLOCK                       = "BLOCK"
+	SIGNING_DOMAIN_FINALIZATION_PROOF          = "FINALIZATION_PROOF"
+	SIGNING_DOMAIN_ANCHOR_ROTATION_PROOF       = "ANCHOR_ROTATION_PROOF"
+	SIGNING_DOMAIN_LEADER_FINALIZATION_PROOF   = "LEADER_FINALIZATION_PROOF"
+	SIGNING_DOMAIN_ANCHOR_MEMBERSHIP           = "ANCHOR_MEMBERSHIP"
+	SIGNING_DOMAIN_NETWORK_PARAMETERS_PROPOSAL = "NETWORK_PARAMETERS_PROPOSAL"
+	SIGNING_DOMAIN_EPOCH_FINISH                = "EPOCH_FINISH"
+	SIGNING_DOMAIN_CREATOR_REINSTATEMENT       = "CREATOR_REINSTATEMENT"
+	SIGNING_DOMAIN_ANCHOR_TRANSACTION          = "ANCHOR_TRANSACTION"
+	SIGNING_DOMAIN_PEER_REQUEST                = "PEER_REQUEST"
+)
+
+func buildSigningPayload(domain string, version int, fields ...string) string {
+
+	header := []string{"MODULR_ANCHORS", domain, "V" + strconv.Itoa(version), globals.GENESIS.NetworkId}
+
+	return strings.Join(append(header, fields...), ":")
+
+}
+
+func IsSupportedSigningPayloadVersion(version int) bool {
+
+	return version >= SIGNING_PAYLOAD_VERSION_LEGACY && version <= LATEST_SIGNING_PAYLOAD_VERSION
+
+}
+
+// GetBlockHashPayload returns data to hash for block. Block signature is made over this hash
+func GetBlockHashPayload(version int, fields ...string) string {
+
+	if version == SIGNING_PAYLOAD_VERSION_LEGACY {
+		return strings.Join(fields, ":")
+	}
+
+	return buildSigningPayload(SIGNING_DOMAIN_BLOCK, version, fields...)
+
+}
+
+func GetFinalizationProofSigningData(version int, prevBlockHash, blockId, blockHash, epochFullID string) string {
+
+	if version == SIGNING_PAYLOAD_VERSION_LEGACY {
+		return strings.Join([]string{prevBlockHash, blockId, blockHash, epochFullID}, ":")
+	}
+
+	return buildSigningPayload(SIGNING_DOMAIN_FINALIZATION_PROOF, version, prevBlockHash, blockId, blockHash, epochFullID)
+
+}
+
+// GetAnchorRotationProofSigningData returns data signed by quorum member to agree that the last finalized block of creator is stat
+func GetAnchorRotationProofSigningData(version int, stat *structures.VotingStat, epochFullID string) string {
+
+	if version == SIGNING_PAYLOAD_VERSION_LEGACY {
+		return strings.Join([]string{stat.Afp.PrevBlockHash, stat.Afp.BlockId, stat.Afp.BlockHash, epochFullID}, ":")
+	}
+
+	return buildSigningPayload(SIGNING_DOMAIN_ANCHOR_ROTATION_PROOF, version, stat.Afp.PrevBlockHash, stat.Afp.BlockId, stat.Afp.BlockHash, epochFullID)
+
+}
+
+// GetLeaderFinalizationProofSigningData returns data signed by modulr-core quorum to finalize the last block of leader
+func GetLeaderFinalizationProofSigningData(version int, leader string, stat *structures.VotingStat, epochFullID string) string {
+
+	if version == SIGNING_PAYLOAD_VERSION_LEGACY {
+		return strings.Join([]string{"LEADER_FINALIZATION_PROOF", leader, strconv.Itoa(stat.Index), stat.Hash, epochFullID}, ":")
+	}
+
+	return buildSigningPayload(SIGNING_DOMAIN_LEADER_FINALIZATION_PROOF, version, leader, strconv.Itoa(stat.Index), stat.Hash, epochFullID)
+
+}
+
+// Membership requests, governance proposals, epoch finish summaries, reinstatements and transactions were introduced with domain separated payloads and have no legacy format
+
+func GetAnchorMembershipSigningData(request *structures.AnchorMembershipRequest) string {
+
+	serializedAnchor, _ := json.Marshal(request.Anchor)
+
+	return buildSigningPayload(SIGNING_DOMAIN_ANCHOR_MEMBERSHIP, SIGNING_PAYLOAD_VERSION_DOMAIN_SEPARATED, request.Type, strconv.Itoa(request.EpochIndex), string(serializedAnchor))
+
+}
+
+func GetNetworkParametersProposalSigningData(proposal *structures.NetworkParametersProposal) string {
+
+	serializedParameters, _ := json.Marshal(proposal.Parameters)
+
+	return buildSigningPayload(SIGNING_DOMAIN_NETWORK_PARAMETERS_PROPOSAL, SIGNING_PAYLOAD_VERSION_DOMAIN_SEPARATED, strconv.Itoa(proposal.EpochIndex), proposal.Proposer, string(serializedParameters))
+
+}
+
+// GetEpochFinishSigningData returns data signed by quorum member to agree on the last finalized block of each creator
+func GetEpochFinishSigningData(summary *structures.EpochFinishSummary, epochFullID string) string {
+
+	creators := make([]string, 0, len(summary.VotingStats))
+
+	for creator := range summary.VotingStats {
+		creators = append(creators, creator)
+	}
+
+	sort.Strings(creators)
+
+	fields := []string{strconv.Itoa(summary.EpochIndex), epochFullID}
+
+	for _, creator := range creators {
+
+		stat := summary.VotingStats[creator]
+
+		fields = append(fields, creator, strconv.Itoa(stat.Index), stat.Hash)
+
+	}
+
+	return buildSigningPayload(SIGNING_DOMAIN_EPOCH_FINISH, SIGNING_PAYLOAD_VERSION_DOMAIN_SEPARATED, fields...)
+
+}
+
+// GetCreatorReinstatementSigningData returns data signed by quorum member to enable proofs for creator which progressed beyond the stalled height
+func GetCreatorReinstatementSigningData(epochIndex int, creator string, afp *structures.AggregatedFinalizationProof, epochFullID string) string {
+
+	return buildSigningPayload(SIGNING_DOMAIN_CREATOR_REINSTATEMENT, SIGNING_PAYLOAD_VERSION_DOMAIN_SEPARATED, strconv.Itoa(epochIndex), creator, afp.BlockId, afp.BlockHash, epochFullID)
+
+}
+
+// GetAnchorTransactionSigningData returns data signed by creator of transaction. Payload is hashed with blake3 and type
+// can't contain ":" (see VerifyAnchorTransaction), so fields can't be shifted from one to another
+func GetAnchorTransactionSigningData(tx *structures.AnchorTransaction) string {
+
+	return buildSigningPayload(SIGNING_DOMAIN_ANCHOR_TRANSACTION, SIGNING_PAYLOAD_VERSION_DOMAIN_SEPARATED, tx.Creator, strconv.FormatUint(tx.Nonce, 10), tx.Type, Blake3(tx.Payload))
+
+}
+
+// GetPeerRequestSigningData returns data signed by anchor which sends HTTP request to another anchor. Body is hashed with blake3
+func GetPeerRequestSigningData(method, path, timestamp, nonce string, body []byte) string {
+
+	return buildSigningPayload(SIGNING_DOMAIN_PEER_REQUEST, SIGNING_PAYLOAD_VERSION_DOMAIN_SEPARATED, method, path, timestamp, nonce, Blake3(string(body)))
+
+}
@@ -0,0 +1,211 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/modulrcloud/modulr-anchors-core/databases"
+	"github.com/modulrcloud/modulr-anchors-core/globals"
+	"github.com/modulrcloud/modulr-anchors-core/structures"
+)
+
+// Kinds of signatures recorded to slashing protection journal
+const (
+	SIGNED_FINALIZATION_PROOF    = "FINALIZATION_PROOF"
+	SIGNED_ANCHOR_ROTATION_PROOF = "ANCHOR_ROTATION_PROOF"
+	SIGNED_EPOCH_FINISH          = "EPOCH_FINISH" // one summary per epoch, creator is empty and index is 0
+)
+
+const SLASHING_PROTECTION_INTERCHANGE_VERSION = 1
+
+var ErrSlashableSignature = errors.New("already signed another hash for this slot")
+
+// Check and record of the entry should be done as one step
+var slashingProtectionMutex sync.Mutex
+
+func signedEntryKey(kind string, epochIndex int, creator string, index int) []byte {
+	return []byte(kind + ":" + strconv.Itoa(epochIndex) + ":" + creator + ":" + strconv.Itoa(index))
+}
+
+func readSignedEntry(key []byte) (*structures.SignedEntry, error) {
+
+	raw, err := databases.SLASHING_PROTECTION.Get(key)
+
+	if errors.Is(err, databases.ErrNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var entry structures.SignedEntry
+
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+
+}
+
+// SignedHash returns hash recorded for the slot or empty string if nothing was signed for it yet
+func SignedHash(kind string, epochIndex int, creator string, index int) (string, error) {
+
+	slashingProtectionMutex.Lock()
+	defer slashingProtectionMutex.Unlock()
+
+	existing, err := readSignedEntry(signedEntryKey(kind, epochIndex, creator, index))
+
+	if err != nil || existing == nil {
+		return "", err
+	}
+
+	return existing.Hash, nil
+
+}
+
+// CheckAndRecordSignature should be called right before signing. It refuses if journal already has another hash
+// for the same (kind, epoch, creator, index), otherwise the entry is synced to disk and signature can be generated.
+// Signing the same hash again is allowed
+func CheckAndRecordSignature(kind string, epochIndex int, creator string, index int, hash string) error {
+
+	slashingProtectionMutex.Lock()
+	defer slashingProtectionMutex.Unlock()
+
+	key := signedEntryKey(kind, epochIndex, creator, index)
+
+	existing, err := readSignedEntry(key)
+
+	if err != nil {
+		return fmt.Errorf("read slashing protection journal: %w", err)
+	}
+
+	if existing != nil {
+
+		if existing.Hash != hash {
+			return ErrSlashableSignature
+		}
+
+		return nil
+
+	}
+
+	payload, err := json.Marshal(structures.SignedEntry{Kind: kind, EpochIndex: epochIndex, Creator: creator, Index: index, Hash: hash})
+
+	if err != nil {
+		return err
+	}
+
+	if err := databases.SLASHING_PROTECTION.Put(key, payload); err != nil {
+		return fmt.Errorf("write slashing protection journal: %w", err)
+	}
+
+	return nil
+
+}
+
+// ExportSlashingProtection returns all the journal entries of our key
+func ExportSlashingProtection() (*structures.SlashingProtectionInterchange, error) {
+
+	slashingProtectionMutex.Lock()
+	defer slashingProtectionMutex.Unlock()
+
+	interchange := &structures.SlashingProtectionInterchange{
+		Metadata: structures.SlashingProtectionMetadata{
+			InterchangeFormatVersion: SLASHING_PROTECTION_INTERCHANGE_VERSION,
+			NetworkId:                globals.GENESIS.NetworkId,
+			Pubkey:                   globals.CONFIGURATION.PublicKey,
+		},
+		Entries: []structures.SignedEntry{},
+	}
+
+	iterator := databases.SLASHING_PROTECTION.NewIterator(nil)
+
+	defer iterator.Release()
+
+	for iterator.Next() {
+
+		var entry structures.SignedEntry
+
+		if err := json.Unmarshal(iterator.Value(), &entry); err != nil {
+			return nil, fmt.Errorf("corrupted journal entry %s: %w", iterator.Key(), err)
+		}
+
+		interchange.Entries = append(interchange.Entries, entry)
+
+	}
+
+	return interchange, iterator.Error()
+
+}
+
+// ImportSlashingProtection merges entries exported on another machine. Existing entries are never overwritten,
+// entries with another hash for the same slot are counted as conflicts
+func ImportSlashingProtection(interchange *structures.SlashingProtectionInterchange) (imported, conflicts int, err error) {
+
+	metadata := interchange.Metadata
+
+	if metadata.InterchangeFormatVersion != SLASHING_PROTECTION_INTERCHANGE_VERSION {
+		return 0, 0, fmt.Errorf("unsupported interchange format version %d", metadata.InterchangeFormatVersion)
+	}
+
+	if metadata.Pubkey != globals.CONFIGURATION.PublicKey {
+		return 0, 0, fmt.Errorf("journal belongs to %s, but node key is %s", metadata.Pubkey, globals.CONFIGURATION.PublicKey)
+	}
+
+	if metadata.NetworkId != globals.GENESIS.NetworkId {
+		return 0, 0, fmt.Errorf("journal is for network %s, but node runs %s", metadata.NetworkId, globals.GENESIS.NetworkId)
+	}
+
+	slashingProtectionMutex.Lock()
+	defer slashingProtectionMutex.Unlock()
+
+	batch := new(databases.Batch)
+
+	for _, entry := range interchange.Entries {
+
+		if entry.Kind != SIGNED_FINALIZATION_PROOF && entry.Kind != SIGNED_ANCHOR_ROTATION_PROOF && entry.Kind != SIGNED_EPOCH_FINISH {
+			return 0, 0, fmt.Errorf("unknown entry kind %s", entry.Kind)
+		}
+
+		key := signedEntryKey(entry.Kind, entry.EpochIndex, entry.Creator, entry.Index)
+
+		existing, err := readSignedEntry(key)
+
+		if err != nil {
+			return 0, 0, err
+		}
+
+		if existing != nil {
+
+			if existing.Hash != entry.Hash {
+				conflicts++
+			}
+
+			continue
+
+		}
+
+		payload, err := json.Marshal(entry)
+
+		if err != nil {
+			return 0, 0, err
+		}
+
+		batch.Put(key, payload)
+
+		imported++
+
+	}
+
+	if err := databases.SLASHING_PROTECTION.Write(batch); err != nil {
+		return 0, 0, err
+	}
+
+	return imported, conflicts, nil
+
+}
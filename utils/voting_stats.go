@@ -7,8 +7,6 @@ import (
 
 	"github.com/modulrcloud/modulr-anchors-core/databases"
 	"github.com/modulrcloud/modulr-anchors-core/structures"
-
-	ldbErrors "github.com/syndtr/goleveldb/leveldb/errors"
 )
 
 func BuildVotingStatKey(epochIndex int, creator string) []byte {
@@ -21,10 +19,10 @@ func ReadVotingStat(epochIndex int, creator string) (structures.VotingStat, erro
 
 	key := BuildVotingStatKey(epochIndex, creator)
 	stat := structures.NewVotingStatTemplate()
-	raw, err := databases.FINALIZATION_VOTING_STATS.Get(key, nil)
+	raw, err := databases.FINALIZATION_VOTING_STATS.Get(key)
 
 	if err != nil {
-		if errors.Is(err, ldbErrors.ErrNotFound) {
+		if errors.Is(err, databases.ErrNotFound) {
 			return stat, nil
 		}
 		return stat, err
@@ -50,6 +48,6 @@ func StoreVotingStat(epochIndex int, creator string, stat structures.VotingStat)
 		return err
 	}
 
-	return databases.FINALIZATION_VOTING_STATS.Put(BuildVotingStatKey(epochIndex, creator), payload, nil)
+	return databases.FINALIZATION_VOTING_STATS.Put(BuildVotingStatKey(epochIndex, creator), payload)
 
 }
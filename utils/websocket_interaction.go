@@ -54,7 +54,7 @@ func OpenWebsocketConnectionsWithQuorum(quorum []string, wsConnMap map[string]*w
 	// Establish new connections for each anchor in the quorum
 	for _, anchorPubkey := range quorum {
 		// Fetch anchor metadata
-		raw, err := databases.APPROVEMENT_THREAD_METADATA.Get([]byte(anchorPubkey+"_ANCHOR_STORAGE"), nil)
+		raw, err := databases.APPROVEMENT_THREAD_METADATA.Get([]byte(anchorPubkey + "_ANCHOR_STORAGE"))
 		if err != nil {
 			continue
 		}
@@ -95,9 +95,10 @@ func NewQuorumWaiter(maxQuorumSize int) *QuorumWaiter {
 	}
 }
 
+// SendAndWait sends the message to quorum and waits until responders accumulate the majority weight
 func (qw *QuorumWaiter) SendAndWait(
 	ctx context.Context, message []byte, quorum []string,
-	wsConnMap map[string]*websocket.Conn, majority int,
+	wsConnMap map[string]*websocket.Conn, quorumWeights map[string]uint64, majority uint64,
 ) (map[string][]byte, bool) {
 
 	// Reset state
@@ -135,10 +136,13 @@ func (qw *QuorumWaiter) SendAndWait(
 				qw.answered[r.id] = struct{}{}
 				qw.responses[r.id] = r.msg
 			}
-			count := len(qw.answered)
+			answeredWeight := uint64(0)
+			for id := range qw.answered {
+				answeredWeight += quorumWeights[id]
+			}
 			qw.mu.Unlock()
 
-			if count >= majority {
+			if answeredWeight >= majority {
 				close(qw.done)
 				// copy responses
 				qw.mu.Lock()
@@ -190,7 +194,7 @@ func getWriteMu(id string) *sync.Mutex {
 func reconnectOnce(pubkey string, wsConnMap map[string]*websocket.Conn) {
 
 	// Get anchor metadata
-	raw, err := databases.APPROVEMENT_THREAD_METADATA.Get([]byte(pubkey+"_ANCHOR_STORAGE"), nil)
+	raw, err := databases.APPROVEMENT_THREAD_METADATA.Get([]byte(pubkey + "_ANCHOR_STORAGE"))
 	if err != nil {
 		return
 	}
@@ -7,7 +7,6 @@ import (
 	"sync"
 
 	"github.com/modulrcloud/modulr-anchors-core/block_pack"
-	"github.com/modulrcloud/modulr-anchors-core/cryptography"
 	"github.com/modulrcloud/modulr-anchors-core/databases"
 	"github.com/modulrcloud/modulr-anchors-core/globals"
 	"github.com/modulrcloud/modulr-anchors-core/handlers"
@@ -79,7 +78,7 @@ func GetFinalizationProof(parsedRequest WsFinalizationProofRequest, connection *
 
 	localVotingDataForLeader := structures.NewVotingStatTemplate()
 
-	localVotingDataRaw, err := databases.FINALIZATION_VOTING_STATS.Get([]byte(strconv.Itoa(epochIndex)+":"+parsedRequest.Block.Creator), nil)
+	localVotingDataRaw, err := databases.FINALIZATION_VOTING_STATS.Get([]byte(strconv.Itoa(epochIndex) + ":" + parsedRequest.Block.Creator))
 
 	if err == nil {
 
@@ -87,24 +86,45 @@ func GetFinalizationProof(parsedRequest WsFinalizationProofRequest, connection *
 
 	}
 
+	// Blocks over MAX_BLOCK_SIZE_IN_BYTES or TXS_LIMIT_PER_BLOCK are never voted for
+
+	if err := parsedRequest.Block.VerifyLimits(&handlers.APPROVEMENT_THREAD_METADATA.Handler.NetworkParameters); err != nil {
+		sendFinalizationProofError(connection, err.Error())
+		return
+	}
+
 	proposedBlockHash := parsedRequest.Block.GetHash()
 
+	proposedBlockId := strconv.Itoa(epochIndex) + ":" + parsedRequest.Block.Creator + ":" + strconv.Itoa(int(parsedRequest.Block.Index))
+
+	// Creator already sent us another block with the same id - keep the evidence and refuse to vote
+
+	if detectBlockEquivocation(&parsedRequest.Block, proposedBlockId, proposedBlockHash, epochHandler) {
+		return
+	}
+
 	itsSameChainSegment := localVotingDataForLeader.Index < int(parsedRequest.Block.Index) || localVotingDataForLeader.Index == int(parsedRequest.Block.Index) && proposedBlockHash == localVotingDataForLeader.Hash && parsedRequest.Block.Epoch == epochFullID
 
 	if itsSameChainSegment {
 
-		proposedBlockId := strconv.Itoa(epochIndex) + ":" + parsedRequest.Block.Creator + ":" + strconv.Itoa(int(parsedRequest.Block.Index))
-
 		previousBlockIndex := int(parsedRequest.Block.Index - 1)
 
 		var futureVotingDataToStore structures.VotingStat
 
-		if parsedRequest.Block.VerifySignature() && !utils.SignalAboutEpochRotationExists(epochIndex) {
+		blockIsValid := parsedRequest.Block.Version == epochHandler.SigningPayloadVersion && parsedRequest.Block.VerifySignature() && parsedRequest.Block.VerifyExtraData(epochHandler)
+
+		if blockIsValid && !utils.SignalAboutEpochRotationExists(epochIndex) {
 
 			creatorMutex.Lock()
 
 			defer creatorMutex.Unlock()
 
+			// Epoch finish summary might be signed while we waited for mutex - no votes beyond it
+
+			if utils.SignalAboutEpochRotationExists(epochIndex) {
+				return
+			}
+
 			if localVotingDataForLeader.Index == int(parsedRequest.Block.Index) {
 
 				futureVotingDataToStore = localVotingDataForLeader
@@ -124,77 +144,96 @@ func GetFinalizationProof(parsedRequest WsFinalizationProofRequest, connection *
 
 			previousBlockId := strconv.Itoa(epochIndex) + ":" + parsedRequest.Block.Creator + ":" + strconv.Itoa(previousBlockIndex)
 
-			// Check if AFP inside related to previous block AFP
+			// Check if AFP inside related to previous block AFP. Block should point to the finalized previous block,
+			// so finalized blocks of creator are linked by PrevHash (see block_pack.CollectEpochChanges)
 
-			if parsedRequest.Block.Index == 0 || previousBlockId == parsedRequest.PreviousBlockAfp.BlockId && utils.VerifyAggregatedFinalizationProof(&parsedRequest.PreviousBlockAfp, epochHandler) {
+			if parsedRequest.Block.Index == 0 || previousBlockId == parsedRequest.PreviousBlockAfp.BlockId && parsedRequest.Block.PrevHash == parsedRequest.PreviousBlockAfp.BlockHash && utils.VerifyAggregatedFinalizationProof(&parsedRequest.PreviousBlockAfp, epochHandler) {
 
 				// Store the block and return finalization proof
 
-				blockBytes, err := json.Marshal(parsedRequest.Block)
+				blockBytes, errBlock := json.Marshal(parsedRequest.Block)
 
-				if err == nil {
+				afpBytes, errAfp := json.Marshal(parsedRequest.PreviousBlockAfp)
 
-					// 1. Store the block
+				votingStatBytes, errStat := json.Marshal(futureVotingDataToStore)
 
-					err = databases.BLOCKS.Put([]byte(proposedBlockId), blockBytes, nil)
+				if errBlock != nil || errAfp != nil || errStat != nil {
+					return
+				}
 
-					if err == nil {
+				// Slashing protection - never sign another hash for this block id, even if chain data was restored from backup
 
-						afpBytes, err := json.Marshal(parsedRequest.PreviousBlockAfp)
+				if err := utils.CheckAndRecordSignature(utils.SIGNED_FINALIZATION_PROOF, epochIndex, parsedRequest.Block.Creator, int(parsedRequest.Block.Index), proposedBlockHash); err != nil {
 
-						if err == nil {
+					utils.LogWithTime("Refused to sign finalization proof for "+proposedBlockId+": "+err.Error(), utils.RED_COLOR)
 
-							// 2. Store the AFP for previous block
+					sendFinalizationProofError(connection, err.Error())
 
-							errStore := databases.EPOCH_DATA.Put([]byte("AFP:"+parsedRequest.PreviousBlockAfp.BlockId), afpBytes, nil)
+					return
 
-							votingStatBytes, errParse := json.Marshal(futureVotingDataToStore)
+				}
 
-							if errStore == nil && errParse == nil {
+				// Block, AFP for previous block, voting stats and payload of previous block are committed as one batch
 
-								// 3. Store the voting stats
+				batch := databases.NewAtomicBatch()
 
-								err := databases.FINALIZATION_VOTING_STATS.Put([]byte(strconv.Itoa(epochIndex)+":"+parsedRequest.Block.Creator), votingStatBytes, nil)
+				batch.Put(databases.BLOCKS, []byte(proposedBlockId), blockBytes)
 
-								if err == nil {
+				batch.Put(databases.EPOCH_DATA, []byte("AFP:"+parsedRequest.PreviousBlockAfp.BlockId), afpBytes)
 
-									// Only after we stored the these 3 components = generate signature (finalization proof)
+				batch.Put(databases.FINALIZATION_VOTING_STATS, []byte(strconv.Itoa(epochIndex)+":"+parsedRequest.Block.Creator), votingStatBytes)
 
-									dataToSign, prevBlockHash := "", ""
+				// Previous block received AFP - process its payload
 
-									if parsedRequest.Block.Index == 0 {
+				if parsedRequest.Block.Index > 0 {
 
-										prevBlockHash = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+					processPreviousFinalizedBlock(epochIndex, previousBlockId, parsedRequest.PreviousBlockAfp.BlockHash, batch)
 
-									} else {
+				}
 
-										prevBlockHash = parsedRequest.PreviousBlockAfp.BlockHash
+				if err := batch.Write(); err != nil {
 
-									}
+					utils.LogWithTime("Failed to store block "+proposedBlockId+": "+err.Error(), utils.RED_COLOR)
 
-									dataToSign += strings.Join([]string{prevBlockHash, proposedBlockId, proposedBlockHash, epochFullID}, ":")
+					return
 
-									response := WsFinalizationProofResponse{
-										Voter:             globals.CONFIGURATION.PublicKey,
-										FinalizationProof: cryptography.GenerateSignature(globals.CONFIGURATION.PrivateKey, dataToSign),
-										VotedForHash:      proposedBlockHash,
-									}
+				}
 
-									jsonResponse, err := json.Marshal(response)
+				// AFP of previous block is accepted - index its votes to catch double votes
 
-									if err == nil {
+				if parsedRequest.Block.Index > 0 {
 
-										connection.WriteMessage(gws.OpcodeText, jsonResponse)
+					utils.RecordAggregatedFinalizationProofVotes(&parsedRequest.PreviousBlockAfp, epochHandler)
 
-									}
+				}
 
-								}
+				// Only after we stored the block, AFP and voting stats = generate signature (finalization proof)
 
-							}
+				prevBlockHash := ""
 
-						}
+				if parsedRequest.Block.Index == 0 {
 
-					}
+					prevBlockHash = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+				} else {
+
+					prevBlockHash = parsedRequest.PreviousBlockAfp.BlockHash
+
+				}
+
+				dataToSign := utils.GetFinalizationProofSigningData(epochHandler.SigningPayloadVersion, prevBlockHash, proposedBlockId, proposedBlockHash, epochFullID)
+
+				response := WsFinalizationProofResponse{
+					Voter:             globals.CONFIGURATION.PublicKey,
+					FinalizationProof: utils.GenerateFinalizationProofSignature(dataToSign),
+					VotedForHash:      proposedBlockHash,
+				}
+
+				jsonResponse, err := json.Marshal(response)
+
+				if err == nil {
+
+					connection.WriteMessage(gws.OpcodeText, jsonResponse)
 
 				}
 
@@ -206,9 +245,76 @@ func GetFinalizationProof(parsedRequest WsFinalizationProofRequest, connection *
 
 }
 
+func sendFinalizationProofError(connection *gws.Conn, reason string) {
+
+	response := WsFinalizationProofResponse{
+		Voter: globals.CONFIGURATION.PublicKey,
+		Error: reason,
+	}
+
+	if jsonResponse, err := json.Marshal(response); err == nil {
+		connection.WriteMessage(gws.OpcodeText, jsonResponse)
+	}
+
+}
+
+func detectBlockEquivocation(proposedBlock *block_pack.Block, proposedBlockId, proposedBlockHash string, epochHandler *structures.EpochDataHandler) bool {
+
+	storedBlock, err := block_pack.LoadBlock(proposedBlockId)
+
+	if err != nil || storedBlock.GetHash() == proposedBlockHash || !proposedBlock.VerifySignature() {
+		return false
+	}
+
+	evidence, err := block_pack.NewBlockEquivocationEvidence(storedBlock, proposedBlock, epochHandler.Id)
+
+	if err != nil || block_pack.VerifyBlockEquivocationEvidence(&evidence, epochHandler) != nil {
+		return false
+	}
+
+	if utils.HasBlockEquivocationEvidence(&evidence) {
+		return true
+	}
+
+	batch := databases.NewAtomicBatch()
+
+	if err := utils.StoreBlockEquivocationEvidence(evidence, batch); err != nil {
+		utils.LogWithTime("Failed to store equivocation evidence for "+proposedBlockId+": "+err.Error(), utils.RED_COLOR)
+		return true
+	}
+
+	if err := batch.Write(); err != nil {
+		utils.LogWithTime("Failed to store equivocation evidence for "+proposedBlockId+": "+err.Error(), utils.RED_COLOR)
+		return true
+	}
+
+	if err := globals.MEMPOOL.AddBlockEquivocationEvidence(evidence); err != nil {
+		utils.LogWithTime("Failed to add equivocation evidence to mempool: "+err.Error(), utils.RED_COLOR)
+	}
+
+	utils.LogWithTime("Equivocation detected: "+proposedBlock.Creator+" signed two different blocks with id "+proposedBlockId, utils.RED_COLOR)
+
+	return true
+
+}
+
+func processPreviousFinalizedBlock(epochIndex int, blockId, blockHash string, batch *databases.AtomicBatch) {
+
+	block, err := block_pack.LoadBlock(blockId)
+
+	if err != nil || block.GetHash() != blockHash {
+		return
+	}
+
+	if err := block_pack.ProcessFinalizedBlock(block, epochIndex, batch); err != nil {
+		utils.LogWithTime("Failed to process finalized block "+blockId+": "+err.Error(), utils.RED_COLOR)
+	}
+
+}
+
 func GetBlockWithAggregatedFinalizationProof(parsedRequest WsBlockWithAfpRequest, connection *gws.Conn) {
 
-	if blockBytes, err := databases.BLOCKS.Get([]byte(parsedRequest.BlockId), nil); err == nil {
+	if blockBytes, err := databases.BLOCKS.Get([]byte(parsedRequest.BlockId)); err == nil {
 
 		var block block_pack.Block
 
@@ -232,7 +338,7 @@ func GetBlockWithAggregatedFinalizationProof(parsedRequest WsBlockWithAfpRequest
 
 					// Remark: To make sure block with index X is 100% approved we need to get the AFP for next block
 
-					if afpBytes, err := databases.EPOCH_DATA.Get([]byte("AFP:"+nextBlockId), nil); err == nil {
+					if afpBytes, err := databases.EPOCH_DATA.Get([]byte("AFP:" + nextBlockId)); err == nil {
 
 						var afp structures.AggregatedFinalizationProof
 
@@ -12,7 +12,11 @@ import (
 	"github.com/lxzan/gws"
 )
 
-type Handler struct{}
+type Handler struct {
+	perIp          *utils.RateLimiter
+	perMessageType *utils.KeyedRateLimiters
+	connections    *utils.ConnectionsLimiter
+}
 
 type IncomingMsg struct {
 	Route string `json:"route"`
@@ -20,7 +24,17 @@ type IncomingMsg struct {
 
 func (h *Handler) OnOpen(conn *gws.Conn) {}
 
-func (h *Handler) OnClose(conn *gws.Conn, err error) {}
+func (h *Handler) OnClose(conn *gws.Conn, err error) {
+
+	if code, ok := err.(interface{ Uint16() uint16 }); ok && code.Uint16() == 1009 {
+		utils.RATE_LIMITS_METRICS.WsMessageTooLarge.Add(1)
+	}
+
+	if ip, ok := conn.Session().Load("ip"); ok {
+		h.connections.Release(ip.(string))
+	}
+
+}
 
 func (h *Handler) OnPing(conn *gws.Conn, payload []byte) {}
 
@@ -30,6 +44,20 @@ func (h *Handler) OnMessage(connection *gws.Conn, message *gws.Message) {
 
 	defer message.Close()
 
+	ip, _ := connection.Session().Load("ip")
+
+	clientIp, _ := ip.(string)
+
+	if !h.perIp.Allow(clientIp) {
+
+		utils.RATE_LIMITS_METRICS.WsRateLimited.Add(1)
+
+		connection.WriteMessage(gws.OpcodeText, []byte(`{"error":"rate_limited"}`))
+
+		return
+
+	}
+
 	var incoming IncomingMsg
 
 	if err := json.Unmarshal(message.Bytes(), &incoming); err != nil {
@@ -40,6 +68,16 @@ func (h *Handler) OnMessage(connection *gws.Conn, message *gws.Message) {
 
 	}
 
+	if !h.perMessageType.Allow(incoming.Route, clientIp) {
+
+		utils.RATE_LIMITS_METRICS.WsRateLimited.Add(1)
+
+		connection.WriteMessage(gws.OpcodeText, []byte(`{"error":"rate_limited"}`))
+
+		return
+
+	}
+
 	switch incoming.Route {
 
 	case "get_finalization_proof":
@@ -72,22 +110,47 @@ func (h *Handler) OnMessage(connection *gws.Conn, message *gws.Message) {
 
 func CreateWebsocketServer() {
 
-	upgrader := gws.NewUpgrader(&Handler{}, &gws.ServerOption{
-		ParallelEnabled:   true,
-		Recovery:          gws.Recovery,
-		PermessageDeflate: gws.PermessageDeflate{Enabled: true},
+	limits := globals.CONFIGURATION.RateLimits
+
+	handler := &Handler{
+		perIp:          utils.NewRateLimiter(limits.WsPerIp),
+		perMessageType: utils.NewKeyedRateLimiters(limits.WsPerMessageType),
+		connections:    utils.NewConnectionsLimiter(limits.WsMaxConnectionsPerIp),
+	}
+
+	upgrader := gws.NewUpgrader(handler, &gws.ServerOption{
+		ParallelEnabled:    true,
+		Recovery:           gws.Recovery,
+		PermessageDeflate:  gws.PermessageDeflate{Enabled: true},
+		ReadMaxPayloadSize: limits.WsMaxMessageSizeInBytes,
 	})
 
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 
+		ip := utils.IpFromAddress(r.RemoteAddr)
+
+		if !handler.connections.Acquire(ip) {
+
+			utils.RATE_LIMITS_METRICS.WsConnectionsRejected.Add(1)
+
+			http.Error(w, "too many connections", http.StatusTooManyRequests)
+
+			return
+
+		}
+
 		conn, err := upgrader.Upgrade(w, r)
 
 		if err != nil {
 
+			handler.connections.Release(ip)
+
 			return
 
 		}
 
+		conn.Session().Store("ip", ip)
+
 		go func() {
 
 			conn.ReadLoop()
@@ -15,6 +15,7 @@ type WsFinalizationProofResponse struct {
 	Voter             string `json:"voter"`
 	FinalizationProof string `json:"finalizationProof"`
 	VotedForHash      string `json:"votedForHash"`
+	Error             string `json:"error,omitempty"` // reason why voter refused to vote for the block
 }
 
 type WsBlockWithAfpRequest struct {